@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -102,13 +103,14 @@ func chainsShowCmd() *cobra.Command {
 			default:
 				fmt.Printf(`chain-id:        %s
 rpc-addr:        %s
+rpc-addrs:       %s
 trusting-period: %s
 default-denom:   %s
 gas:             %d
-gas-prices:      %s
+gas-prices:      %s (effective %s)
 key:             %s
 account-prefix:  %s
-`, c.ChainID, c.RPCAddr, c.TrustingPeriod, c.DefaultDenom, c.Gas, c.GasPrices, c.Key, c.AccountPrefix)
+`, c.ChainID, c.RPCAddr, strings.Join(c.RPCAddrs, ","), c.TrustingPeriod, c.DefaultDenom, c.Gas, c.GasPrices, c.EffectiveGasPrices(), c.Key, c.AccountPrefix)
 				return nil
 			}
 		},
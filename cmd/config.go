@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path"
 	"strings"
@@ -243,6 +244,30 @@ func (c *Config) ChainsFromPath(path string) (map[string]*relayer.Chain, string,
 	return chains, src, dst, nil
 }
 
+// PathChains takes the path name and returns copies of its chains with the path set.
+// Unlike ChainsFromPath the configured chains are not modified, so the same chain
+// can be used by more than one path at a time.
+func (c *Config) PathChains(path string) (src, dst *relayer.Chain, err error) {
+	pth, err := c.Paths.Get(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chains, err := c.Chains.Gets(pth.Src.ChainID, pth.Dst.ChainID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if src, err = chains[pth.Src.ChainID].WithPath(pth.Src); err != nil {
+		return nil, nil, err
+	}
+	if dst, err = chains[pth.Dst.ChainID].WithPath(pth.Dst); err != nil {
+		return nil, nil, err
+	}
+
+	return src, dst, nil
+}
+
 // MustYAML returns the yaml string representation of the Paths
 func (c Config) MustYAML() []byte {
 	out, err := yaml.Marshal(c)
@@ -264,13 +289,21 @@ func defaultConfig() []byte {
 type GlobalConfig struct {
 	Timeout       string `yaml:"timeout" json:"timeout"`
 	LiteCacheSize int    `yaml:"lite-cache-size" json:"lite-cache-size"`
+
+	// KeyringBackend is used by chains that don't set their own keyring-backend
+	KeyringBackend        string `yaml:"keyring-backend,omitempty" json:"keyring-backend,omitempty"`
+	KeyringPassphraseFile string `yaml:"keyring-passphrase-file,omitempty" json:"keyring-passphrase-file,omitempty"`
+
+	// MisbehaviourCommand is run with sh when a client is found to have accepted a conflicting header
+	MisbehaviourCommand string `yaml:"misbehaviour-command,omitempty" json:"misbehaviour-command,omitempty"`
 }
 
 // newDefaultGlobalConfig returns a global config with defaults set
 func newDefaultGlobalConfig() GlobalConfig {
 	return GlobalConfig{
-		Timeout:       "10s",
-		LiteCacheSize: 20,
+		Timeout:        "10s",
+		LiteCacheSize:  20,
+		KeyringBackend: "test",
 	}
 }
 
@@ -308,7 +341,13 @@ func validateConfig(c *Config) error {
 		return fmt.Errorf("Did you remember to run 'rly config init' error:%w", err)
 	}
 
+	if c.Global.LiteCacheSize < 0 || c.Global.LiteCacheSize > math.MaxUint16 {
+		return fmt.Errorf("lite-cache-size must be between 0 and %d, got %d", math.MaxUint16, c.Global.LiteCacheSize)
+	}
+
 	for _, i := range c.Chains {
+		i.SetKeyringDefaults(c.Global.KeyringBackend, c.Global.KeyringPassphraseFile)
+		i.SetLiteCacheSize(uint16(c.Global.LiteCacheSize))
 		if err := i.Init(homePath, appCodec, cdc, to, debug); err != nil {
 			return fmt.Errorf("Did you remember to run 'rly config init' error:%w", err)
 		}
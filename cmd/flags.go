@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/iqlusioninc/relayer/relayer"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -26,6 +29,11 @@ var (
 	flagOrder        = "unordered"
 	flagMaxTxSize    = "max-tx-size"
 	flagMaxMsgLength = "max-msgs"
+	flagAll          = "all"
+	flagAccount      = "account"
+	flagIndex        = "index"
+	flagAlgo         = "algo"
+	flagTokenFile    = "token-file"
 )
 
 func liteFlags(cmd *cobra.Command) *cobra.Command {
@@ -175,8 +183,10 @@ func urlFlag(cmd *cobra.Command) *cobra.Command {
 }
 
 func strategyFlag(cmd *cobra.Command) *cobra.Command {
-	cmd.Flags().StringP(flagMaxTxSize, "s", "2", "maximum size (in MB) of the messages in a relay transaction")
-	cmd.Flags().StringP(flagMaxMsgLength, "l", "5", "maximum number of messages in a relay transaction")
+	cmd.Flags().StringP(flagMaxTxSize, "s", strconv.Itoa(relayer.DefaultMaxTxSize),
+		"maximum size (in MB) of the messages in a relay transaction, overrides the path's strategy options")
+	cmd.Flags().StringP(flagMaxMsgLength, "l", strconv.Itoa(relayer.DefaultMaxMsgLength),
+		"maximum number of messages in a relay transaction, overrides the path's strategy options")
 	if err := viper.BindPFlag(flagMaxTxSize, cmd.Flags().Lookup(flagMaxTxSize)); err != nil {
 		panic(err)
 	}
@@ -186,6 +196,38 @@ func strategyFlag(cmd *cobra.Command) *cobra.Command {
 	return cmd
 }
 
+func hdPathFlags(cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().Uint32(flagAccount, 0, "account number in the HD derivation path")
+	cmd.Flags().Uint32(flagIndex, 0, "address index number in the HD derivation path")
+	cmd.Flags().String(flagAlgo, string(hd.Secp256k1Type), "key signing algorithm to generate the key for")
+	if err := viper.BindPFlag(flagAccount, cmd.Flags().Lookup(flagAccount)); err != nil {
+		panic(err)
+	}
+	if err := viper.BindPFlag(flagIndex, cmd.Flags().Lookup(flagIndex)); err != nil {
+		panic(err)
+	}
+	if err := viper.BindPFlag(flagAlgo, cmd.Flags().Lookup(flagAlgo)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func allFlag(cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().BoolP(flagAll, "a", false, "run the relayer over all configured paths")
+	if err := viper.BindPFlag(flagAll, cmd.Flags().Lookup(flagAll)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func tokenFileFlag(cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().String(flagTokenFile, "", "file holding the token that requests must carry, required on tcp")
+	if err := viper.BindPFlag(flagTokenFile, cmd.Flags().Lookup(flagTokenFile)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
 func getAddInputs(cmd *cobra.Command) (file string, url string, err error) {
 	file, err = cmd.Flags().GetString(flagFile)
 	if err != nil {
@@ -16,11 +16,15 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 
 	ckeys "github.com/cosmos/cosmos-sdk/client/keys"
-	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 
 	"github.com/iqlusioninc/relayer/relayer"
 )
@@ -39,6 +43,7 @@ func keysCmd() *cobra.Command {
 	cmd.AddCommand(keysListCmd())
 	cmd.AddCommand(keysShowCmd())
 	cmd.AddCommand(keysExportCmd())
+	cmd.AddCommand(keysServeCmd())
 
 	return cmd
 }
@@ -70,12 +75,17 @@ func keysAddCmd() *cobra.Command {
 				return errKeyExists(keyName)
 			}
 
+			account, index, algo, err := getHDPathFlags(cmd)
+			if err != nil {
+				return err
+			}
+
 			mnemonic, err := relayer.CreateMnemonic()
 			if err != nil {
 				return err
 			}
 
-			info, err := chain.Keybase.NewAccount(keyName, mnemonic, "", hd.CreateHDPath(118, 0, 0).String(), hd.Secp256k1)
+			info, err := chain.RestoreKey(keyName, mnemonic, account, index, algo)
 			if err != nil {
 				return err
 			}
@@ -86,7 +96,19 @@ func keysAddCmd() *cobra.Command {
 		},
 	}
 
-	return cmd
+	return hdPathFlags(cmd)
+}
+
+// getHDPathFlags returns the account, index and algo to derive a key with
+func getHDPathFlags(cmd *cobra.Command) (account, index uint32, algo string, err error) {
+	if account, err = cmd.Flags().GetUint32(flagAccount); err != nil {
+		return
+	}
+	if index, err = cmd.Flags().GetUint32(flagIndex); err != nil {
+		return
+	}
+	algo, err = cmd.Flags().GetString(flagAlgo)
+	return
 }
 
 type keyOutput struct {
@@ -115,7 +137,12 @@ func keysRestoreCmd() *cobra.Command {
 				return errKeyExists(keyName)
 			}
 
-			info, err := chain.Keybase.NewAccount(keyName, args[2], "", hd.CreateHDPath(118, 0, 0).String(), hd.Secp256k1)
+			account, index, algo, err := getHDPathFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			info, err := chain.RestoreKey(keyName, args[2], account, index, algo)
 			if err != nil {
 				return err
 			}
@@ -125,7 +152,7 @@ func keysRestoreCmd() *cobra.Command {
 		},
 	}
 
-	return cmd
+	return hdPathFlags(cmd)
 }
 
 // keysDeleteCmd respresents the `keys delete` command
@@ -154,7 +181,7 @@ func keysDeleteCmd() *cobra.Command {
 
 			// TODO: prompt to delete with flag to ignore
 
-			err = chain.Keybase.Delete(keyName)
+			err = chain.DeleteKey(keyName)
 			if err != nil {
 				panic(err)
 			}
@@ -189,7 +216,11 @@ func keysListCmd() *cobra.Command {
 			}
 
 			for d, i := range info {
-				fmt.Printf("key(%d): %s -> %s\n", d, i.GetName(), i.GetAddress().String())
+				hdPath, err := chain.KeyHDPath(i.GetName())
+				if err != nil {
+					return err
+				}
+				fmt.Printf("key(%d): %s -> %s (%s)\n", d, i.GetName(), i.GetAddress().String(), hdPath)
 			}
 
 			return nil
@@ -231,12 +262,61 @@ func keysShowCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println(info.GetAddress().String())
+			hdPath, err := chain.KeyHDPath(keyName)
+			if err != nil {
+				return err
+			}
+
+			jsn, err := cmd.Flags().GetBool(flagJSON)
+			if err != nil {
+				return err
+			}
+			yml, err := cmd.Flags().GetBool(flagYAML)
+			if err != nil {
+				return err
+			}
+
+			ks := keyShowOutput{
+				Name:    info.GetName(),
+				Address: info.GetAddress().String(),
+				Algo:    string(info.GetAlgo()),
+				HDPath:  hdPath,
+			}
+
+			switch {
+			case yml && jsn:
+				return fmt.Errorf("can't pass both --json and --yaml, must pick one")
+			case yml:
+				out, err := yaml.Marshal(ks)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(out))
+			case jsn:
+				out, err := json.Marshal(ks)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(out))
+			default:
+				// the hd path goes to stderr so the output can still be used as an address
+				fmt.Println(ks.Address)
+				if ks.HDPath != "" {
+					fmt.Fprintf(os.Stderr, "hd-path: %s\n", ks.HDPath)
+				}
+			}
 			return nil
 		},
 	}
 
-	return cmd
+	return yamlFlag(jsonFlag(cmd))
+}
+
+type keyShowOutput struct {
+	Name    string `json:"name" yaml:"name"`
+	Address string `json:"address" yaml:"address"`
+	Algo    string `json:"algo" yaml:"algo"`
+	HDPath  string `json:"hd-path" yaml:"hd-path"`
 }
 
 // keysExportCmd respresents the `keys export` command
@@ -257,7 +337,15 @@ func keysExportCmd() *cobra.Command {
 				return errKeyDoesntExist(keyName)
 			}
 
-			info, err := chain.Keybase.ExportPrivKeyArmor(keyName, ckeys.DefaultKeyPass)
+			// keys in the test keyring are exported with the well-known test passphrase
+			pass := ckeys.DefaultKeyPass
+			if chain.GetKeyringBackend() != keyring.BackendTest {
+				if pass, err = chain.KeyExportPassphrase(); err != nil {
+					return err
+				}
+			}
+
+			info, err := chain.Keybase.ExportPrivKeyArmor(keyName, pass)
 			if err != nil {
 				return err
 			}
@@ -268,3 +356,68 @@ func keysExportCmd() *cobra.Command {
 
 	return cmd
 }
+
+// keysServeCmd respresents the `keys serve` command
+func keysServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve [listen-addr] [chain-id...]",
+		Short: "serves signatures with the keys of the given chains, or all chains, to relayers configured with a remote-signer",
+		Long: strings.TrimSpace(`Runs a signer daemon that signs txs for relayers whose chains set remote-signer to
+listen-addr, so their private keys never leave this host. listen-addr is either
+unix:///path/to/socket, which only the owner can connect to, or tcp://host:port
+on a loopback interface, which requires --token-file. Requests aren't encrypted,
+so relayers on other hosts reach the daemon through ssh or unix socket forwarding.
+Requests must carry the token in the token file if one is given. Only the keys each chain relays with (key and keys) are served.`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			chains := config.Chains
+			if len(args) > 1 {
+				chains = nil
+				for _, chainID := range args[1:] {
+					chain, err := config.Chains.Get(chainID)
+					if err != nil {
+						return err
+					}
+					chains = append(chains, chain)
+				}
+			}
+
+			tokenFile, err := cmd.Flags().GetString(flagTokenFile)
+			if err != nil {
+				return err
+			}
+			token, err := relayer.ReadSignerToken(tokenFile)
+			if err != nil {
+				return err
+			}
+
+			signers := make(map[string]relayer.Signer)
+			keys := make(map[string][]string)
+			for _, chain := range chains {
+				if chain.RemoteSigner != "" {
+					return fmt.Errorf("chain %s signs with a remote signer and its keys can't be served", chain.ChainID)
+				}
+				signers[chain.ChainID] = relayer.NewKeyringSigner(chain.Keybase)
+				keys[chain.ChainID] = chain.KeyNames()
+			}
+
+			l, err := relayer.ListenSigner(args[0], token)
+			if err != nil {
+				return err
+			}
+
+			go func() {
+				if err := relayer.ServeSigner(l, relayer.NewSignerService(signers, keys, token)); err != nil {
+					fmt.Fprintf(os.Stderr, "signer stopped: %s\n", err)
+				}
+			}()
+			fmt.Printf("serving keys for %d chain(s) on %s\n", len(signers), args[0])
+
+			// closing the listener also removes a unix socket
+			trapSignal(func() { _ = l.Close() })
+			return nil
+		},
+	}
+
+	return tokenFileFlag(cmd)
+}
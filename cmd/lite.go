@@ -37,12 +37,19 @@ func liteCmd() *cobra.Command {
 		Use:     "lite",
 		Aliases: []string{"l"},
 		Short:   "manage lite clients held by the relayer for each chain",
+		Long: `Manage the lite clients held by the relayer for each chain.
+
+The lite client database of a chain can only be opened by one relayer process
+at a time. While 'rly start' relays over a chain it holds the chain's database,
+so these commands, and queries that verify proofs, fail after a few seconds of
+retrying until it stops.`,
 	}
 
 	cmd.AddCommand(liteHeaderCmd())
 	cmd.AddCommand(initLiteCmd())
 	cmd.AddCommand(updateLiteCmd())
 	cmd.AddCommand(deleteLiteCmd())
+	cmd.AddCommand(pruneLiteCmd())
 
 	return cmd
 }
@@ -63,12 +70,6 @@ func initLiteCmd() *cobra.Command {
 				return err
 			}
 
-			db, df, err := chain.NewLiteDB()
-			if err != nil {
-				return err
-			}
-			defer df()
-
 			url, err := cmd.Flags().GetString(flagURL)
 			if err != nil {
 				return err
@@ -88,12 +89,12 @@ func initLiteCmd() *cobra.Command {
 
 			switch {
 			case force: // force initialization from trusted node
-				_, err = chain.TrustNodeInitClient(db)
+				err = chain.TrustNodeInitClient()
 				if err != nil {
 					return err
 				}
 			case height > 0 && len(hash) > 0: // height and hash are given
-				_, err = chain.InitLiteClient(db, chain.TrustOptions(height, hash))
+				err = chain.InitLiteClient(chain.TrustOptions(height, hash))
 				if err != nil {
 					return wrapInitFailed(err)
 				}
@@ -108,7 +109,7 @@ func initLiteCmd() *cobra.Command {
 					return err
 				}
 
-				_, err = chain.InitLiteClient(db, to)
+				err = chain.InitLiteClient(to)
 				if err != nil {
 					return wrapInitFailed(err)
 				}
@@ -151,13 +152,7 @@ func updateLiteCmd() *cobra.Command {
 
 			switch {
 			case height > 0 && len(hash) > 0: // height and hash are given
-				db, df, err := chain.NewLiteDB()
-				if err != nil {
-					return err
-				}
-				defer df()
-
-				_, err = chain.InitLiteClient(db, chain.TrustOptions(height, hash))
+				err = chain.InitLiteClient(chain.TrustOptions(height, hash))
 				if err != nil {
 					return wrapInitFailed(err)
 				}
@@ -172,13 +167,7 @@ func updateLiteCmd() *cobra.Command {
 					return err
 				}
 
-				db, df, err := chain.NewLiteDB()
-				if err != nil {
-					return err
-				}
-				defer df()
-
-				_, err = chain.InitLiteClient(db, to)
+				err = chain.InitLiteClient(to)
 				if err != nil {
 					return wrapInitFailed(err)
 				}
@@ -256,6 +245,14 @@ func liteHeaderCmd() *cobra.Command {
 				return err
 			}
 
+			info, err := chain.LiteStoreInfo()
+			if err != nil {
+				return err
+			}
+			if out, err = json.Marshal(liteHeaderOutput{Header: out, Store: info}); err != nil {
+				return err
+			}
+
 			fmt.Println(string(out))
 			return nil
 		},
@@ -263,6 +260,13 @@ func liteHeaderCmd() *cobra.Command {
 	return cmd
 }
 
+// liteHeaderOutput is the header printed by lite header along with the state of
+// the lite client database it was read from
+type liteHeaderOutput struct {
+	Header json.RawMessage       `json:"header"`
+	Store  relayer.LiteStoreInfo `json:"store"`
+}
+
 func deleteLiteCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "delete [chain-id]",
@@ -287,6 +291,30 @@ func deleteLiteCmd() *cobra.Command {
 	return cmd
 }
 
+func pruneLiteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "prune [chain-id]",
+		Aliases: []string{"p"},
+		Short:   "delete all but the lite-cache-size latest headers from the lite client database",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			chain, err := config.Chains.Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			pruned, err := chain.PruneLite()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("pruned %d headers from the %s lite client database\n", pruned, chain.ChainID)
+			return nil
+		},
+	}
+	return cmd
+}
+
 func queryTrustOptions(url string) (out lite.TrustOptions, err error) {
 	// fetch from URL
 	res, err := http.Get(url)
@@ -6,6 +6,7 @@ import (
 	"github.com/tendermint/tendermint/types/time"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	connTypes "github.com/cosmos/cosmos-sdk/x/ibc/03-connection/types"
 	chanTypes "github.com/cosmos/cosmos-sdk/x/ibc/04-channel/types"
@@ -71,9 +72,7 @@ func pathsGenCmd() *cobra.Command {
 					ChainID: dst,
 					PortID:  dstPort,
 				},
-				Strategy: &relayer.StrategyCfg{
-					Type: "naive",
-				},
+				Strategy: relayer.NewNaiveStrategy(),
 			}
 			c, err := config.Chains.Gets(src, dst)
 			if err != nil {
@@ -549,6 +548,20 @@ func pathsShowCmd() *cobra.Command {
 `, args[0], path.Strategy.Type, path.Src.ChainID, path.Src.ClientID, path.Src.ConnectionID, path.Src.ChannelID, path.Src.PortID,
 					path.Dst.ChainID, path.Dst.ClientID, path.Dst.ConnectionID, path.Dst.ChannelID, path.Dst.PortID,
 					checkmark(chains), checkmark(clients), checkmark(connection), checkmark(channel))
+				opts, err := path.Strategy.ResolvedOptions()
+				if err != nil {
+					return err
+				}
+				if opts != nil {
+					out, err := yaml.Marshal(opts)
+					if err != nil {
+						return err
+					}
+					fmt.Println("  OPTIONS:")
+					for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+						fmt.Printf("    %s\n", line)
+					}
+				}
 			}
 
 			return nil
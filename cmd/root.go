@@ -17,6 +17,7 @@ package cmd
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"strings"
 
@@ -24,12 +25,13 @@ import (
 	"github.com/cosmos/cosmos-sdk/codec"
 	codecstd "github.com/cosmos/cosmos-sdk/std"
 	gaia "github.com/cosmos/gaia/app"
+	"github.com/iqlusioninc/relayer/relayer"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 const (
-	MB = 1048576 // in bytes
+	MB = relayer.MB // in bytes
 )
 
 var (
@@ -115,7 +117,14 @@ func Execute() {
 		return initConfig(rootCmd)
 	}
 
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if config != nil {
+		// the lite client databases are held open for the life of the process
+		if cerr := config.Chains.CloseLite(); cerr != nil {
+			fmt.Fprintln(os.Stderr, cerr)
+		}
+	}
+	if err != nil {
 		os.Exit(1)
 	}
 }
@@ -18,7 +18,9 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"os/signal"
+	"sort"
 	"syscall"
 
 	"github.com/iqlusioninc/relayer/relayer"
@@ -29,23 +31,49 @@ import (
 // NOTE: This is basically psuedocode
 func startCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "start [path-name]",
+		Use:     "start [path-name...]",
 		Aliases: []string{"st"},
-		Short:   "Start the listening relayer on a given path",
-		Args:    cobra.ExactArgs(1),
+		Short:   "Start the listening relayer on the given paths, or all configured paths with --all",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c, src, dst, err := config.ChainsFromPath(args[0])
+			all, err := cmd.Flags().GetBool(flagAll)
 			if err != nil {
 				return err
 			}
 
-			path := config.Paths.MustGet(args[0])
-			strategy, err := GetStrategyWithOptions(cmd, path.MustGetStrategy())
+			names, err := startPathNames(args, all)
 			if err != nil {
 				return err
 			}
 
-			done, err := relayer.RunStrategy(c[src], c[dst], strategy, path.Ordered())
+			var paths []*relayer.RelayPath
+			for _, name := range names {
+				src, dst, err := config.PathChains(name)
+				if err != nil {
+					return err
+				}
+
+				path := config.Paths.MustGet(name)
+				strategy, err := GetStrategyWithOptions(cmd, path.MustGetStrategy())
+				if err != nil {
+					return err
+				}
+
+				paths = append(paths, &relayer.RelayPath{
+					Src:           src,
+					Dst:           dst,
+					Strategy:      strategy,
+					Ordered:       path.Ordered(),
+					Filter:        path.Strategy.Filter,
+					Workers:       path.Strategy.Workers,
+					QueueSize:     path.Strategy.QueueSize,
+					DropEvents:    path.Strategy.DropEvents,
+					ClientRefresh: path.Strategy.ClientRefresh,
+
+					OnMisbehaviour: misbehaviourCommand(config.Global.MisbehaviourCommand),
+				})
+			}
+
+			done, err := relayer.RunStrategies(paths...)
 			if err != nil {
 				return err
 			}
@@ -54,7 +82,52 @@ func startCmd() *cobra.Command {
 			return nil
 		},
 	}
-	return strategyFlag(cmd)
+	return allFlag(strategyFlag(cmd))
+}
+
+// startPathNames returns the names of the paths to start the relayer on
+func startPathNames(args []string, all bool) ([]string, error) {
+	switch {
+	case all && len(args) > 0:
+		return nil, fmt.Errorf("pass either path names or --all, not both")
+	case all:
+		names := make([]string, 0, len(config.Paths))
+		for name := range config.Paths {
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no paths configured")
+		}
+		sort.Strings(names)
+		return names, nil
+	case len(args) == 0:
+		return nil, fmt.Errorf("must pass at least one path name or --all")
+	default:
+		return args, nil
+	}
+}
+
+// misbehaviourCommand returns a misbehaviour handler that runs command with sh,
+// passing it the details of the misbehaviour in RLY_* environment variables, or
+// nil if command is empty
+func misbehaviourCommand(command string) func(relayer.Misbehaviour) error {
+	if command == "" {
+		return nil
+	}
+	return func(m relayer.Misbehaviour) error {
+		c := exec.Command("sh", "-c", command)
+		c.Env = append(os.Environ(),
+			"RLY_CHAIN_ID="+m.ChainID,
+			"RLY_CLIENT_ID="+m.ClientID,
+			"RLY_COUNTERPARTY_CHAIN_ID="+m.CounterpartyChainID,
+			fmt.Sprintf("RLY_HEIGHT=%d", m.Height),
+			fmt.Sprintf("RLY_ACCEPTED_HASH=%X", m.AcceptedHash),
+			fmt.Sprintf("RLY_CONFLICTING_HASH=%X", m.ConflictingHash),
+			fmt.Sprintf("RLY_SUBMITTED=%t", m.Submitted),
+		)
+		c.Stdout, c.Stderr = os.Stdout, os.Stderr
+		return c.Run()
+	}
 }
 
 // trap signal waits for a SIGINT or SIGTERM and then sends down the done channel
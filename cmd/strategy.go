@@ -1,51 +1,48 @@
 package cmd
 
 import (
-	"fmt"
 	"strconv"
 
 	"github.com/iqlusioninc/relayer/relayer"
 	"github.com/spf13/cobra"
 )
 
-// GetStrategyWithOptions sets strategy specific fields.
+// GetStrategyWithOptions sets strategy specific fields. Values passed by
+// flags override those set in the path's strategy config.
 func GetStrategyWithOptions(cmd *cobra.Command, strategy relayer.Strategy) (relayer.Strategy, error) {
-	switch strategy.GetType() {
-	case (&relayer.NaiveStrategy{}).GetType():
-		ns, ok := strategy.(*relayer.NaiveStrategy)
-		if !ok {
-			return strategy, fmt.Errorf("strategy.GetType() returns naive, but strategy type (%T) is not type NaiveStrategy", strategy)
+	bs, ok := strategy.(relayer.BatchedStrategy)
+	if !ok {
+		return strategy, nil
+	}
 
-		}
+	maxTxSize, maxMsgLength := bs.BatchLimits()
 
-		maxTxSize, err := cmd.Flags().GetString(flagMaxTxSize)
+	if cmd.Flags().Changed(flagMaxTxSize) {
+		txSize, err := cmd.Flags().GetString(flagMaxTxSize)
 		if err != nil {
-			return ns, err
+			return bs, err
 		}
 
-		txSize, err := strconv.ParseUint(maxTxSize, 10, 64)
-		if err != nil {
-			return ns, err
+		if maxTxSize, err = strconv.ParseUint(txSize, 10, 64); err != nil {
+			return bs, err
 		}
 
 		// set max size of messages in a relay transaction
-		ns.MaxTxSize = txSize * MB // in MB
-
-		maxMsgLength, err := cmd.Flags().GetString(flagMaxMsgLength)
-		if err != nil {
-			return ns, err
-		}
+		maxTxSize *= MB // in MB
+	}
 
-		msgLen, err := strconv.ParseUint(maxMsgLength, 10, 64)
+	if cmd.Flags().Changed(flagMaxMsgLength) {
+		msgLen, err := cmd.Flags().GetString(flagMaxMsgLength)
 		if err != nil {
-			return ns, err
+			return bs, err
 		}
 
 		// set max length messages in relay transaction
-		ns.MaxMsgLength = msgLen
-
-		return ns, nil
-	default:
-		return strategy, nil
+		if maxMsgLength, err = strconv.ParseUint(msgLen, 10, 64); err != nil {
+			return bs, err
+		}
 	}
+
+	bs.SetBatchLimits(maxTxSize, maxMsgLength)
+	return bs, nil
 }
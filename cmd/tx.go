@@ -195,22 +195,20 @@ func relayMsgsCmd() *cobra.Command {
 				return err
 			}
 
-			sp, err := relayer.UnrelayedSequences(c[src], c[dst], sh)
-			if err != nil {
-				return err
-			}
-
 			path := config.Paths.MustGet(args[0])
 			strategy, err := GetStrategyWithOptions(cmd, path.MustGetStrategy())
 			if err != nil {
 				return err
 			}
 
-			if err = strategy.RelayPacketsOrderedChan(c[src], c[dst], sp, sh); err != nil {
-				return err
+			rp := &relayer.RelayPath{
+				Src:      c[src],
+				Dst:      c[dst],
+				Strategy: strategy,
+				Ordered:  path.Ordered(),
+				Filter:   path.Strategy.Filter,
 			}
-
-			return nil
+			return rp.RelayUnrelayed(sh)
 		},
 	}
 
@@ -0,0 +1,110 @@
+package relayer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	sdkCtx "github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+)
+
+const (
+	// defaultBroadcastTimeout is how long a tx broadcast in sync or async mode
+	// is waited for before it is broadcast again
+	defaultBroadcastTimeout = 30 * time.Second
+	// maxBroadcastAttempts is the number of times a tx is broadcast before giving up on it
+	maxBroadcastAttempts = 3
+	// txPollInterval is the time between queries for a broadcast tx
+	txPollInterval = time.Second
+)
+
+// validateBroadcastMode checks that the chain's broadcast mode is supported
+func validateBroadcastMode(mode string) error {
+	switch mode {
+	case "", flags.BroadcastBlock, flags.BroadcastSync, flags.BroadcastAsync:
+		return nil
+	default:
+		return fmt.Errorf("invalid broadcast-mode (%s), must be one of sync, async or block", mode)
+	}
+}
+
+// broadcastMode returns the chain's broadcast mode, sync if it isn't set
+func (src *Chain) broadcastMode() string {
+	if src.BroadcastMode == "" {
+		return flags.BroadcastSync
+	}
+	return src.BroadcastMode
+}
+
+// Broadcast sends the tx with the chain's broadcast mode. In block mode it waits for
+// the tx to be committed. In sync and async mode the tx is confirmed by polling the
+// chain for it and is broadcast again if it isn't in a block within the broadcast
+// timeout. A tx that fails CheckTx is returned without waiting. Async mode doesn't
+// report CheckTx, so the tx is broadcast again in sync mode, which does.
+func (src *Chain) Broadcast(txBytes []byte) (res sdk.TxResponse, err error) {
+	mode := src.broadcastMode()
+	if mode == flags.BroadcastBlock {
+		return src.BroadcastTxCommit(txBytes)
+	}
+
+	hash := fmt.Sprintf("%X", tmhash.Sum(txBytes))
+	ctx := sdkCtx.CLIContext{Client: src.Client, BroadcastMode: mode}
+	for attempt := 1; attempt <= maxBroadcastAttempts; attempt++ {
+		if attempt > 1 {
+			ctx.BroadcastMode = flags.BroadcastSync
+		}
+		if res, err = ctx.BroadcastTx(txBytes); err != nil {
+			return res, err
+		}
+
+		// a tx already in the mempool cache was accepted by an earlier broadcast
+		if res.Code != 0 && res.Code != sdkerrors.ErrTxInMempoolCache.ABCICode() {
+			return res, nil
+		}
+
+		if res, err = src.waitForTx(hash); err == nil {
+			return res, nil
+		}
+
+		if attempt < maxBroadcastAttempts {
+			src.Log(fmt.Sprintf("- [%s] tx %s not in a block after %s, broadcasting again (attempt %d)",
+				src.ChainID, hash, src.getBroadcastTimeout(), attempt+1))
+		}
+	}
+	return res, fmt.Errorf("tx %s on chain %s not in a block after %d broadcasts: %w",
+		hash, src.ChainID, maxBroadcastAttempts, err)
+}
+
+// waitForTx polls the chain for the tx with the given hash until it is found
+// or the broadcast timeout passes. The tx is not verified against the lite client,
+// the relayer only learns whether its own tx was committed from it.
+func (src *Chain) waitForTx(hash string) (sdk.TxResponse, error) {
+	deadline := time.Now().Add(src.getBroadcastTimeout())
+	for {
+		res, err := src.queryTx(hash, false)
+		if err == nil {
+			return res, nil
+		}
+		if !isErrTxNotFound(err) || time.Now().After(deadline) {
+			return res, err
+		}
+		time.Sleep(txPollInterval)
+	}
+}
+
+// getBroadcastTimeout returns the chain's broadcast timeout or the default if it isn't set
+func (src *Chain) getBroadcastTimeout() time.Duration {
+	if src.broadcastTimeout == 0 {
+		return defaultBroadcastTimeout
+	}
+	return src.broadcastTimeout
+}
+
+// isErrTxNotFound returns true if the node doesn't have the tx (yet)
+func isErrTxNotFound(err error) bool {
+	return strings.Contains(err.Error(), "not found")
+}
@@ -12,14 +12,12 @@ import (
 	"time"
 
 	sdkCtx "github.com/cosmos/cosmos-sdk/client/context"
-	ckeys "github.com/cosmos/cosmos-sdk/client/keys"
 	aminocodec "github.com/cosmos/cosmos-sdk/codec"
 	"github.com/cosmos/cosmos-sdk/crypto/hd"
 	keys "github.com/cosmos/cosmos-sdk/crypto/keyring"
 	codecstd "github.com/cosmos/cosmos-sdk/std"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/auth"
-	authclient "github.com/cosmos/cosmos-sdk/x/auth/client"
 	"github.com/cosmos/go-bip39"
 	"github.com/tendermint/tendermint/libs/log"
 	rpcclient "github.com/tendermint/tendermint/rpc/client"
@@ -41,6 +39,29 @@ type Chain struct {
 	Memo           string  `yaml:"memo,omitempty" json:"memo,omitempty"`
 	TrustingPeriod string  `yaml:"trusting-period" json:"trusting-period"`
 
+	BroadcastMode    string  `yaml:"broadcast-mode,omitempty" json:"broadcast-mode,omitempty"`
+	BroadcastTimeout string  `yaml:"broadcast-timeout,omitempty" json:"broadcast-timeout,omitempty"`
+	KeyringBackend   string  `yaml:"keyring-backend,omitempty" json:"keyring-backend,omitempty"`
+	CoinType         *uint32 `yaml:"coin-type,omitempty" json:"coin-type,omitempty"`
+	RemoteSigner     string  `yaml:"remote-signer,omitempty" json:"remote-signer,omitempty"`
+	// RemoteSignerTokenFile holds the token that authenticates requests to the remote signer
+	RemoteSignerTokenFile string `yaml:"remote-signer-token-file,omitempty" json:"remote-signer-token-file,omitempty"`
+
+	// Keys are signing keys used alongside Key to send relay txs in parallel
+	Keys          []string `yaml:"keys,omitempty" json:"keys,omitempty"`
+	MinKeyBalance string   `yaml:"min-key-balance,omitempty" json:"min-key-balance,omitempty"`
+
+	// RPCAddrs are rpc endpoints that are failed over to when RPCAddr is unhealthy
+	RPCAddrs []string `yaml:"rpc-addrs,omitempty" json:"rpc-addrs,omitempty"`
+	// WitnessAddrs are rpc endpoints the lite client cross-checks headers against
+	WitnessAddrs []string `yaml:"witness-addrs,omitempty" json:"witness-addrs,omitempty"`
+	// SkipProofVerification turns off the verification of store and tx query proofs
+	SkipProofVerification bool `yaml:"skip-proof-verification,omitempty" json:"skip-proof-verification,omitempty"`
+
+	LiteOptions *LiteOptions `yaml:"lite-options,omitempty" json:"lite-options,omitempty"`
+
+	FeePolicy *FeePolicy `yaml:"fee-policy,omitempty" json:"fee-policy,omitempty"`
+
 	// TODO: make these private
 	HomePath string                `yaml:"-" json:"-"`
 	PathEnd  *PathEnd              `yaml:"-" json:"-"`
@@ -54,8 +75,37 @@ type Chain struct {
 	timeout time.Duration
 	debug   bool
 
+	broadcastTimeout time.Duration
+
+	defaultKeyringBackend string
+	keyringPassphraseFile string
+
+	// the number of trusted headers kept in the lite client database
+	liteCacheSize uint16
+
+	// signs the chain's txs, either with the keybase or a remote signer
+	signer Signer
+
 	// stores facuet addresses that have been used reciently
 	faucetAddrs map[string]time.Time
+
+	// hands out the sequences of the chain's accounts, shared by copies of the chain
+	sequences *accountSequences
+
+	// spreads relay txs over the chain's keys, shared by copies of the chain
+	pool *keyPool
+
+	// the gas prices set by the fee policy, shared by copies of the chain
+	fees *feeState
+
+	// set when the lite client's witnesses diverge, shared by copies of the chain
+	diverged *divergence
+
+	// the latest height known to be committed, shared by copies of the chain
+	heights *blockHeights
+
+	// the chain's open lite client, shared by copies of the chain
+	lite *liteHandle
 }
 
 // ListenRPCEmitJSON listens for tx and block events from a chain and outputs them as JSON to stdout
@@ -125,14 +175,19 @@ func (src *Chain) listenLoop(doneChan chan struct{}, tx, block, data bool) {
 // Init initializes the pieces of a chain that aren't set when it parses a config
 // NOTE: All validation of the chain should happen here.
 func (src *Chain) Init(homePath string, cdc *codecstd.Codec, amino *aminocodec.Codec, timeout time.Duration, debug bool) error {
-	keybase, err := keys.New(src.ChainID, "test", keysDir(homePath, src.ChainID), nil)
+	backend := src.GetKeyringBackend()
+	if err := validateKeyringBackend(backend); err != nil {
+		return fmt.Errorf("%w for chain %s", err, src.ChainID)
+	}
+
+	keybase, err := src.newKeyring(backend, keysDir(homePath, src.ChainID))
 	if err != nil {
 		return err
 	}
 
-	client, err := newRPCClient(src.RPCAddr, timeout)
+	client, err := newFailoverClient(src.ChainID, src.RPCEndpoints(), timeout, src.Log)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w for chain %s", err, src.ChainID)
 	}
 
 	_, err = sdk.ParseDecCoins(src.GasPrices)
@@ -140,12 +195,60 @@ func (src *Chain) Init(homePath string, cdc *codecstd.Codec, amino *aminocodec.C
 		return err
 	}
 
+	var fees *feeState
+	if src.FeePolicy != nil {
+		if err = src.FeePolicy.init(src.GasPrices); err != nil {
+			return fmt.Errorf("%w for chain %s", err, src.ChainID)
+		}
+		if fees, err = loadFeeState(feesFile(homePath, src.ChainID), src.FeePolicy); err != nil {
+			return err
+		}
+	}
+
+	if src.LiteOptions != nil {
+		if err = src.LiteOptions.init(src.ChainID, homePath); err != nil {
+			return fmt.Errorf("%w for chain %s", err, src.ChainID)
+		}
+	}
+
+	for _, addr := range src.WitnessAddrs {
+		if _, err = rpchttp.New(addr, "/websocket"); err != nil {
+			return fmt.Errorf("invalid witness-addr (%s) for chain %s: %w", addr, src.ChainID, err)
+		}
+	}
+
+	if _, err = sdk.ParseCoins(src.MinKeyBalance); err != nil {
+		return fmt.Errorf("failed to parse min key balance (%s) for chain %s: %w", src.MinKeyBalance, src.ChainID, err)
+	}
+
 	_, err = time.ParseDuration(src.TrustingPeriod)
 	if err != nil {
 		return fmt.Errorf("failed to parse trusting period (%s) for chain %s", src.TrustingPeriod, src.ChainID)
 	}
 
+	if err = validateBroadcastMode(src.BroadcastMode); err != nil {
+		return fmt.Errorf("%w for chain %s", err, src.ChainID)
+	}
+
+	if src.BroadcastTimeout != "" {
+		if src.broadcastTimeout, err = time.ParseDuration(src.BroadcastTimeout); err != nil {
+			return fmt.Errorf("failed to parse broadcast timeout (%s) for chain %s", src.BroadcastTimeout, src.ChainID)
+		}
+	}
+
+	signer := NewKeyringSigner(keybase)
+	if src.RemoteSigner != "" {
+		token, err := ReadSignerToken(src.RemoteSignerTokenFile)
+		if err != nil {
+			return fmt.Errorf("%w for chain %s", err, src.ChainID)
+		}
+		if signer, err = NewRemoteSigner(src.RemoteSigner, src.ChainID, token, timeout); err != nil {
+			return fmt.Errorf("%w for chain %s", err, src.ChainID)
+		}
+	}
+
 	src.Keybase = keybase
+	src.signer = signer
 	src.Client = client
 	src.Cdc = newContextualStdCodec(cdc, src.UseSDKContext)
 	src.Amino = newContextualAminoCodec(amino, src.UseSDKContext)
@@ -155,6 +258,12 @@ func (src *Chain) Init(homePath string, cdc *codecstd.Codec, amino *aminocodec.C
 	src.timeout = timeout
 	src.debug = debug
 	src.faucetAddrs = make(map[string]time.Time)
+	src.sequences = newAccountSequences()
+	src.pool = newKeyPool(src.KeyNames())
+	src.fees = fees
+	src.diverged = &divergence{}
+	src.heights = &blockHeights{}
+	src.lite = &liteHandle{}
 	return nil
 }
 
@@ -173,8 +282,7 @@ func (src *Chain) KeyExists(name string) bool {
 }
 
 func (src *Chain) getGasPrices() sdk.DecCoins {
-	gp, _ := sdk.ParseDecCoins(src.GasPrices)
-	return gp
+	return src.sendGasPrices()
 }
 
 // GetTrustingPeriod returns the trusting period for the chain
@@ -206,11 +314,7 @@ func (src *Chain) SendMsg(datagram sdk.Msg) (sdk.TxResponse, error) {
 
 // SendMsgs wraps the msgs in a stdtx, signs and sends it
 func (src *Chain) SendMsgs(datagrams []sdk.Msg) (res sdk.TxResponse, err error) {
-	var out []byte
-	if out, err = src.BuildAndSignTx(datagrams); err != nil {
-		return res, err
-	}
-	return src.BroadcastTxCommit(out)
+	return src.sendMsgs(datagrams, src.Key, src.MustGetAddress())
 }
 
 // BuildAndSignTx takes messages and builds, signs and marshals a sdk.Tx to prepare it for broadcast
@@ -223,18 +327,25 @@ func (src *Chain) BuildAndSignTx(msgs []sdk.Msg) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	ctx := sdkCtx.CLIContext{Client: src.Client}
+	return src.buildAndSignTx(msgs, src.Key, acc.GetAccountNumber(), acc.GetSequence(), 0)
+}
+
+// buildAndSignTx builds, signs with the named key and marshals a sdk.Tx with the
+// given account and sequence numbers. The gas limit is at least minGas.
+func (src *Chain) buildAndSignTx(msgs []sdk.Msg, keyName string, accNum, seq, minGas uint64) (out []byte, err error) {
+	done := src.UseSDKContext()
+	defer done()
+
 	txBldr := auth.NewTxBuilder(
-		auth.DefaultTxEncoder(src.Amino.Codec), acc.GetAccountNumber(),
-		acc.GetSequence(), src.Gas, src.GasAdjustment, true, src.ChainID,
-		src.Memo, sdk.NewCoins(), src.getGasPrices()).WithKeybase(src.Keybase)
-	if src.GasAdjustment > 0 {
-		txBldr, err = authclient.EnrichWithGas(txBldr, ctx, msgs)
-		if err != nil {
-			return nil, err
-		}
+		auth.DefaultTxEncoder(src.Amino.Codec), accNum,
+		seq, src.Gas, src.getGasAdjustment(), true, src.ChainID,
+		src.Memo, sdk.NewCoins(), src.getGasPrices())
+
+	gas, err := src.gasLimit(txBldr, msgs, minGas)
+	if err != nil {
+		return nil, err
 	}
-	return txBldr.BuildAndSign(src.Key, ckeys.DefaultKeyPass, msgs)
+	return src.signTx(txBldr.WithGas(gas), keyName, msgs)
 }
 
 // BroadcastTxCommit takes the marshaled transaction bytes and broadcasts them
@@ -259,13 +370,18 @@ func (src *Chain) Start() error {
 
 // Subscribe returns channel of events given a query
 func (src *Chain) Subscribe(query string) (<-chan ctypes.ResultEvent, context.CancelFunc, error) {
+	return src.subscribe(src.Client, query)
+}
+
+// subscribe returns channel of events given a query, subscribing with the passed client
+func (src *Chain) subscribe(client rpcclient.Client, query string) (<-chan ctypes.ResultEvent, context.CancelFunc, error) {
 	suffix, err := GenerateRandomString(8)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	eventChan, err := src.Client.Subscribe(ctx, fmt.Sprintf("%s-subscriber-%s", src.ChainID, suffix), query, 1000)
+	eventChan, err := client.Subscribe(ctx, fmt.Sprintf("%s-subscriber-%s", src.ChainID, suffix), query, 1000)
 	return eventChan, cancel, err
 }
 
@@ -278,6 +394,10 @@ func liteDir(home string) string {
 	return path.Join(home, "lite")
 }
 
+func liteDBDir(home, chainID string) string {
+	return path.Join(liteDir(home), fmt.Sprintf("%s.db", chainID))
+}
+
 // GetAddress returns the sdk.AccAddress associated with the configred key
 func (src *Chain) GetAddress() (sdk.AccAddress, error) {
 	if src.address != nil {
@@ -285,12 +405,12 @@ func (src *Chain) GetAddress() (sdk.AccAddress, error) {
 	}
 
 	// Signing key for src chain
-	srcAddr, err := src.Keybase.Key(src.Key)
+	srcAddr, err := src.KeyAddress(src.Key)
 	if err != nil {
 		return nil, err
 	}
 
-	src.address = srcAddr.GetAddress()
+	src.address = srcAddr
 	return src.address, nil
 }
 
@@ -478,7 +598,7 @@ func (src *Chain) CreateTestKey() error {
 		return err
 	}
 
-	_, err = src.Keybase.NewAccount(src.Key, mnemonic, "", hd.CreateHDPath(118, 0, 0).String(), hd.Secp256k1)
+	_, err = src.RestoreKey(src.Key, mnemonic, 0, 0, string(hd.Secp256k1Type))
 	return err
 }
 
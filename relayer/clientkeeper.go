@@ -0,0 +1,120 @@
+package relayer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	tmclient "github.com/cosmos/cosmos-sdk/x/ibc/07-tendermint/types"
+)
+
+const (
+	// clientKeeperInterval is how often the clients of a running path are checked
+	clientKeeperInterval = time.Minute
+	// DefaultClientRefresh is the fraction of a client's trusting period after which
+	// it is updated even if there are no packets to relay
+	DefaultClientRefresh = 1.0 / 3
+)
+
+// keepClients updates the clients on both ends of the path whenever they haven't
+// been updated for the path's client refresh fraction of their trusting period,
+// so that quiet paths don't let their clients expire, and submits evidence of
+// misbehaviour against clients that accepted conflicting headers. It runs until
+// doneChan is closed.
+func (rp *RelayPath) keepClients(doneChan <-chan struct{}, wg *sync.WaitGroup, sh *SyncHeaders) {
+	defer wg.Done()
+
+	refresh := rp.ClientRefresh
+	if refresh <= 0 {
+		refresh = DefaultClientRefresh
+	}
+
+	ticker := time.NewTicker(clientKeeperInterval)
+	defer ticker.Stop()
+
+	srcCheck, dstCheck := &clientCheck{}, &clientCheck{}
+	for {
+		rp.refreshClient(rp.Src, rp.Dst, sh, refresh, srcCheck)
+		rp.refreshClient(rp.Dst, rp.Src, sh, refresh, dstCheck)
+
+		select {
+		case <-ticker.C:
+		case <-doneChan:
+			return
+		}
+	}
+}
+
+// refreshClient checks the client of dst on src for misbehaviour, then updates it
+// with dst's latest header if the client is due for a refresh
+func (rp *RelayPath) refreshClient(src, dst *Chain, sh *SyncHeaders, refresh float64, check *clientCheck) {
+	res, err := src.QueryClientState()
+	switch {
+	case err != nil:
+		src.Error(fmt.Errorf("client keeper failed to query client(%s): %w", src.PathEnd.ClientID, err))
+		return
+	case res == nil:
+		src.Error(fmt.Errorf("client keeper found no client(%s) of %s", src.PathEnd.ClientID, dst.ChainID))
+		return
+	}
+
+	cs, ok := res.ClientState.(tmclient.ClientState)
+	switch {
+	case !ok:
+		src.Error(fmt.Errorf("client keeper can't refresh client(%s) of type %s",
+			src.PathEnd.ClientID, res.ClientState.ClientType()))
+		return
+	case cs.IsFrozen():
+		src.Error(fmt.Errorf("client(%s) of %s is frozen and can't be updated", src.PathEnd.ClientID, dst.ChainID))
+		return
+	case rp.checkMisbehaviour(src, dst, sh, cs, check):
+		return
+	}
+
+	trustingPeriod := cs.TrustingPeriod
+	age := time.Since(cs.GetLatestTimestamp())
+	switch due, expired := clientRefreshDue(age, trustingPeriod, refresh); {
+	case expired:
+		src.Error(fmt.Errorf("client(%s) of %s expired %s ago and can't be updated",
+			src.PathEnd.ClientID, dst.ChainID, age-trustingPeriod))
+		return
+	case !due:
+		return
+	}
+
+	// the synced header may lag while the listener reconnects, so fetch a fresh one
+	header := sh.GetHeader(dst.ChainID)
+	if header == nil || header.GetHeight() <= cs.GetLatestHeight() {
+		var err error
+		if header, err = dst.UpdateLiteWithHeader(); err != nil {
+			src.Error(fmt.Errorf("client(%s) of %s is due for a refresh, %s left of its trusting period, but no header could be fetched: %w",
+				src.PathEnd.ClientID, dst.ChainID, (trustingPeriod - age).Round(time.Second), err))
+			return
+		}
+		if header.GetHeight() <= cs.GetLatestHeight() {
+			src.Error(fmt.Errorf("client(%s) of %s is due for a refresh, %s left of its trusting period, but %s has no header newer than %d",
+				src.PathEnd.ClientID, dst.ChainID, (trustingPeriod - age).Round(time.Second), dst.ChainID, cs.GetLatestHeight()))
+			return
+		}
+	}
+
+	src.Log(fmt.Sprintf("- [%s] client(%s) of %s was last updated %s ago, %.0f%% of its %s trusting period, updating to height %d",
+		src.ChainID, src.PathEnd.ClientID, dst.ChainID, age.Round(time.Second),
+		float64(age)*100/float64(trustingPeriod), trustingPeriod, header.GetHeight()))
+
+	if br := send(src, []sdk.Msg{src.PathEnd.UpdateClient(header, src.MustGetAddress())}); br.Success() {
+		src.Log(fmt.Sprintf("★ Client updated: [%s]client(%s) {%d}->{%d}",
+			src.ChainID, src.PathEnd.ClientID, cs.GetLatestHeight(), header.GetHeight()))
+	}
+}
+
+// clientRefreshDue returns whether a client last updated age ago is due for a
+// refresh, once the refresh fraction of its trusting period has passed, and
+// whether it has expired
+func clientRefreshDue(age, trustingPeriod time.Duration, refresh float64) (due, expired bool) {
+	if age >= trustingPeriod {
+		return false, true
+	}
+	return age >= time.Duration(float64(trustingPeriod)*refresh), false
+}
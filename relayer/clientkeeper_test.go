@@ -0,0 +1,39 @@
+package relayer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRefreshDue(t *testing.T) {
+	const day = 24 * time.Hour
+
+	tests := []struct {
+		name           string
+		age            time.Duration
+		trustingPeriod time.Duration
+		refresh        float64
+		due, expired   bool
+	}{
+		{"just updated", 0, 21 * day, DefaultClientRefresh, false, false},
+		{"before refresh", 7*day - time.Second, 21 * day, DefaultClientRefresh, false, false},
+		{"at refresh", 7 * day, 21 * day, DefaultClientRefresh, true, false},
+		{"after refresh", 10 * day, 21 * day, DefaultClientRefresh, true, false},
+		{"near expiry", 21*day - time.Second, 21 * day, DefaultClientRefresh, true, false},
+		{"at expiry", 21 * day, 21 * day, DefaultClientRefresh, false, true},
+		{"expired", 30 * day, 21 * day, DefaultClientRefresh, false, true},
+		{"custom refresh not reached", 10 * day, 20 * day, 0.75, false, false},
+		{"custom refresh reached", 15 * day, 20 * day, 0.75, true, false},
+		{"full refresh not reached", 20*day - time.Second, 20 * day, 1, false, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			due, expired := clientRefreshDue(tc.age, tc.trustingPeriod, tc.refresh)
+			require.Equal(t, tc.due, due)
+			require.Equal(t, tc.expired, expired)
+		})
+	}
+}
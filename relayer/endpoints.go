@@ -0,0 +1,432 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/service"
+	litep "github.com/tendermint/tendermint/lite2/provider"
+	litehttp "github.com/tendermint/tendermint/lite2/provider/http"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	// rpcHealthCheckInterval is how often the rpc endpoints of a chain are checked
+	rpcHealthCheckInterval = 30 * time.Second
+	// maxRPCHeightLag is how many blocks an endpoint may be behind the others and stay healthy
+	maxRPCHeightLag = 5
+)
+
+// RPCEndpoints returns the rpc endpoints of the chain in order of preference.
+// RPCAddr is always first.
+func (src *Chain) RPCEndpoints() []string {
+	addrs := []string{src.RPCAddr}
+	seen := map[string]bool{src.RPCAddr: true}
+	for _, addr := range src.RPCAddrs {
+		if !seen[addr] {
+			addrs = append(addrs, addr)
+			seen[addr] = true
+		}
+	}
+	return addrs
+}
+
+// ActiveRPCAddr returns the rpc endpoint the chain currently sends requests to
+func (src *Chain) ActiveRPCAddr() string {
+	if fc, ok := src.Client.(*failoverClient); ok {
+		return fc.Remote()
+	}
+	return src.RPCAddr
+}
+
+// watchRPCEndpoints checks the health of the chain's rpc endpoints periodically
+// until doneChan is closed
+func (src *Chain) watchRPCEndpoints(doneChan <-chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	if fc, ok := src.Client.(*failoverClient); ok {
+		fc.watchHealth(doneChan)
+	}
+}
+
+// liteProvider returns the provider the chain's lite client fetches headers from,
+// which fails over between the chain's rpc endpoints along with its Client
+func (src *Chain) liteProvider() (litep.Provider, error) {
+	if fc, ok := src.Client.(*failoverClient); ok {
+		return litehttp.NewWithClient(src.ChainID, fc), nil
+	}
+	return litehttp.New(src.ChainID, src.RPCAddr)
+}
+
+type rpcEndpoint struct {
+	addr    string
+	client  *rpchttp.HTTP
+	healthy bool
+	height  int64
+}
+
+// failoverClient is an rpc client that sends each request to the most preferred
+// healthy endpoint of a chain. An endpoint is unhealthy if it can't be reached, is
+// catching up or lags the other endpoints by more than maxRPCHeightLag blocks. The
+// endpoints are checked every rpcHealthCheckInterval while the client is in use or
+// watched, and a request that fails to reach an endpoint is retried on the next one.
+// Broadcasts are never retried, as the tx may have reached the failed endpoint.
+type failoverClient struct {
+	*service.BaseService
+
+	chainID string
+	log     func(string)
+
+	mu        sync.Mutex
+	endpoints []*rpcEndpoint
+	active    *rpcEndpoint
+	checked   time.Time
+	checking  bool
+}
+
+var _ rpcclient.Client = (*failoverClient)(nil)
+
+func newFailoverClient(chainID string, addrs []string, timeout time.Duration, log func(string)) (*failoverClient, error) {
+	fc := &failoverClient{chainID: chainID, log: log}
+	for _, addr := range addrs {
+		client, err := newRPCClient(addr, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rpc-addr (%s): %w", addr, err)
+		}
+		fc.endpoints = append(fc.endpoints, &rpcEndpoint{addr: addr, client: client, healthy: true})
+	}
+	fc.active = fc.endpoints[0]
+	fc.BaseService = service.NewBaseService(nil, "failoverClient", fc)
+	return fc, nil
+}
+
+// current returns the endpoint to send requests to, starting a health check of
+// the endpoints in the background if they haven't been checked recently
+func (fc *failoverClient) current() *rpcEndpoint {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if len(fc.endpoints) > 1 && !fc.checking && time.Since(fc.checked) >= rpcHealthCheckInterval {
+		fc.checking = true
+		go fc.checkHealth()
+	}
+	return fc.active
+}
+
+// watchHealth checks the health of the endpoints every rpcHealthCheckInterval until
+// doneChan is closed, so that a recovered endpoint is switched back to while the
+// chain is idle
+func (fc *failoverClient) watchHealth(doneChan <-chan struct{}) {
+	if len(fc.endpoints) < 2 {
+		return
+	}
+
+	ticker := time.NewTicker(rpcHealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fc.mu.Lock()
+			checking := fc.checking
+			fc.checking = true
+			fc.mu.Unlock()
+			if !checking {
+				fc.checkHealth()
+			}
+		case <-doneChan:
+			return
+		}
+	}
+}
+
+// checkHealth queries the status of every endpoint and switches to the most
+// preferred healthy one
+func (fc *failoverClient) checkHealth() {
+	var wg sync.WaitGroup
+	stats := make([]*ctypes.ResultStatus, len(fc.endpoints))
+	for i, ep := range fc.endpoints {
+		wg.Add(1)
+		go func(i int, ep *rpcEndpoint) {
+			defer wg.Done()
+			if stat, err := ep.client.Status(); err == nil {
+				stats[i] = stat
+			}
+		}(i, ep)
+	}
+	wg.Wait()
+
+	var maxHeight int64
+	for _, stat := range stats {
+		if stat != nil && stat.SyncInfo.LatestBlockHeight > maxHeight {
+			maxHeight = stat.SyncInfo.LatestBlockHeight
+		}
+	}
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	for i, ep := range fc.endpoints {
+		stat := stats[i]
+		switch {
+		case stat == nil:
+			ep.healthy = false
+		default:
+			ep.height = stat.SyncInfo.LatestBlockHeight
+			ep.healthy = !stat.SyncInfo.CatchingUp && maxHeight-ep.height <= maxRPCHeightLag
+		}
+	}
+	fc.checked, fc.checking = time.Now(), false
+	fc.switchEndpoint()
+}
+
+// switchEndpoint makes the most preferred healthy endpoint the active one. The
+// active endpoint is kept if none are healthy. fc.mu must be held.
+func (fc *failoverClient) switchEndpoint() {
+	for _, ep := range fc.endpoints {
+		if !ep.healthy {
+			continue
+		}
+		if ep != fc.active {
+			fc.log(fmt.Sprintf("- [%s] switching rpc endpoint from %s to %s", fc.chainID, fc.active.addr, ep.addr))
+			fc.active = ep
+		}
+		return
+	}
+}
+
+// failed marks an endpoint that couldn't be reached as unhealthy and returns the
+// endpoint to retry on, or nil if there is none
+func (fc *failoverClient) failed(ep *rpcEndpoint, tried map[*rpcEndpoint]bool, err error) *rpcEndpoint {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if ep.healthy {
+		fc.log(fmt.Sprintf("- [%s] rpc endpoint %s failed: %s", fc.chainID, ep.addr, err))
+		ep.healthy = false
+	}
+	if ep == fc.active {
+		fc.switchEndpoint()
+	}
+	for _, next := range fc.endpoints {
+		if next.healthy && !tried[next] {
+			return next
+		}
+	}
+	return nil
+}
+
+// do runs call against the current endpoint, and against the other healthy endpoints
+// in turn for as long as it fails to reach them
+func (fc *failoverClient) do(call func(c *rpchttp.HTTP) error) error {
+	tried := make(map[*rpcEndpoint]bool)
+	for ep := fc.current(); ; {
+		tried[ep] = true
+		err := call(ep.client)
+		if !isEndpointFailure(err) {
+			return err
+		}
+		if ep = fc.failed(ep, tried, err); ep == nil {
+			return err
+		}
+	}
+}
+
+// doOnce runs call against the current endpoint only, marking the endpoint as
+// unhealthy if it can't be reached. It is used for calls that must not be repeated.
+func (fc *failoverClient) doOnce(call func(c *rpchttp.HTTP) error) error {
+	ep := fc.current()
+	err := call(ep.client)
+	if isEndpointFailure(err) {
+		fc.failed(ep, map[*rpcEndpoint]bool{ep: true}, err)
+	}
+	return err
+}
+
+// isEndpointFailure returns true if err was returned because the endpoint couldn't
+// be reached or answered badly, rather than by the node handling the request
+func isEndpointFailure(err error) bool {
+	var rpcErr *rpctypes.RPCError
+	return err != nil && !errors.As(err, &rpcErr)
+}
+
+// Remote returns the address of the active endpoint
+func (fc *failoverClient) Remote() string {
+	return fc.current().addr
+}
+
+// OnStart starts the active endpoint's client, opening its websocket
+func (fc *failoverClient) OnStart() error {
+	return fc.current().client.Start()
+}
+
+// OnStop stops the clients of all endpoints that were started
+func (fc *failoverClient) OnStop() {
+	for _, ep := range fc.endpoints {
+		if ep.client.IsRunning() {
+			_ = ep.client.Stop()
+		}
+	}
+}
+
+func (fc *failoverClient) Status() (res *ctypes.ResultStatus, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.Status(); return })
+	return
+}
+
+func (fc *failoverClient) ABCIInfo() (res *ctypes.ResultABCIInfo, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.ABCIInfo(); return })
+	return
+}
+
+func (fc *failoverClient) ABCIQuery(path string, data bytes.HexBytes) (res *ctypes.ResultABCIQuery, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.ABCIQuery(path, data); return })
+	return
+}
+
+func (fc *failoverClient) ABCIQueryWithOptions(path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions) (res *ctypes.ResultABCIQuery, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.ABCIQueryWithOptions(path, data, opts); return })
+	return
+}
+
+func (fc *failoverClient) BroadcastTxCommit(tx tmtypes.Tx) (res *ctypes.ResultBroadcastTxCommit, err error) {
+	err = fc.doOnce(func(c *rpchttp.HTTP) (err error) { res, err = c.BroadcastTxCommit(tx); return })
+	return
+}
+
+func (fc *failoverClient) BroadcastTxAsync(tx tmtypes.Tx) (res *ctypes.ResultBroadcastTx, err error) {
+	err = fc.doOnce(func(c *rpchttp.HTTP) (err error) { res, err = c.BroadcastTxAsync(tx); return })
+	return
+}
+
+func (fc *failoverClient) BroadcastTxSync(tx tmtypes.Tx) (res *ctypes.ResultBroadcastTx, err error) {
+	err = fc.doOnce(func(c *rpchttp.HTTP) (err error) { res, err = c.BroadcastTxSync(tx); return })
+	return
+}
+
+func (fc *failoverClient) Block(height *int64) (res *ctypes.ResultBlock, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.Block(height); return })
+	return
+}
+
+func (fc *failoverClient) BlockResults(height *int64) (res *ctypes.ResultBlockResults, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.BlockResults(height); return })
+	return
+}
+
+func (fc *failoverClient) Commit(height *int64) (res *ctypes.ResultCommit, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.Commit(height); return })
+	return
+}
+
+func (fc *failoverClient) Validators(height *int64, page, perPage int) (res *ctypes.ResultValidators, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.Validators(height, page, perPage); return })
+	return
+}
+
+func (fc *failoverClient) Tx(hash []byte, prove bool) (res *ctypes.ResultTx, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.Tx(hash, prove); return })
+	return
+}
+
+func (fc *failoverClient) TxSearch(query string, prove bool, page, perPage int, orderBy string) (res *ctypes.ResultTxSearch, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.TxSearch(query, prove, page, perPage, orderBy); return })
+	return
+}
+
+func (fc *failoverClient) Genesis() (res *ctypes.ResultGenesis, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.Genesis(); return })
+	return
+}
+
+func (fc *failoverClient) BlockchainInfo(minHeight, maxHeight int64) (res *ctypes.ResultBlockchainInfo, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.BlockchainInfo(minHeight, maxHeight); return })
+	return
+}
+
+func (fc *failoverClient) NetInfo() (res *ctypes.ResultNetInfo, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.NetInfo(); return })
+	return
+}
+
+func (fc *failoverClient) DumpConsensusState() (res *ctypes.ResultDumpConsensusState, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.DumpConsensusState(); return })
+	return
+}
+
+func (fc *failoverClient) ConsensusState() (res *ctypes.ResultConsensusState, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.ConsensusState(); return })
+	return
+}
+
+func (fc *failoverClient) ConsensusParams(height *int64) (res *ctypes.ResultConsensusParams, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.ConsensusParams(height); return })
+	return
+}
+
+func (fc *failoverClient) Health() (res *ctypes.ResultHealth, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.Health(); return })
+	return
+}
+
+func (fc *failoverClient) UnconfirmedTxs(limit int) (res *ctypes.ResultUnconfirmedTxs, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.UnconfirmedTxs(limit); return })
+	return
+}
+
+func (fc *failoverClient) NumUnconfirmedTxs() (res *ctypes.ResultUnconfirmedTxs, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.NumUnconfirmedTxs(); return })
+	return
+}
+
+func (fc *failoverClient) BroadcastEvidence(ev tmtypes.Evidence) (res *ctypes.ResultBroadcastEvidence, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) (err error) { res, err = c.BroadcastEvidence(ev); return })
+	return
+}
+
+// Subscribe subscribes over the websocket of the active endpoint. Subscriptions
+// don't move when the active endpoint changes, so long lived subscribers should
+// resubscribe when ActiveRPCAddr changes.
+func (fc *failoverClient) Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (out <-chan ctypes.ResultEvent, err error) {
+	err = fc.do(func(c *rpchttp.HTTP) error {
+		if !c.IsRunning() {
+			if err := c.Start(); err != nil {
+				return err
+			}
+		}
+		out, err = c.Subscribe(ctx, subscriber, query, outCapacity...)
+		return err
+	})
+	return
+}
+
+// Unsubscribe removes the subscription from whichever endpoint it was made on
+func (fc *failoverClient) Unsubscribe(ctx context.Context, subscriber, query string) (err error) {
+	err = fmt.Errorf("subscription not found")
+	for _, ep := range fc.endpoints {
+		if ep.client.IsRunning() {
+			if err = ep.client.Unsubscribe(ctx, subscriber, query); err == nil {
+				return nil
+			}
+		}
+	}
+	return err
+}
+
+// UnsubscribeAll removes the subscriber's subscriptions from every endpoint
+func (fc *failoverClient) UnsubscribeAll(ctx context.Context, subscriber string) (err error) {
+	err = fmt.Errorf("subscription not found")
+	for _, ep := range fc.endpoints {
+		if ep.client.IsRunning() {
+			if ep.client.UnsubscribeAll(ctx, subscriber) == nil {
+				err = nil
+			}
+		}
+	}
+	return err
+}
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"time"
 
-	ckeys "github.com/cosmos/cosmos-sdk/client/keys"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/auth"
 	"github.com/cosmos/cosmos-sdk/x/bank"
@@ -15,19 +14,21 @@ import (
 
 // SendMsgWithKey allows the user to specify which relayer key will sign the message
 func (src *Chain) SendMsgWithKey(datagram sdk.Msg, keyName string) (res sdk.TxResponse, err error) {
-	var out []byte
-	if out, err = src.BuildAndSignTxWithKey([]sdk.Msg{datagram}, keyName); err != nil {
+	addr, err := src.KeyAddress(keyName)
+	if err != nil {
 		return res, err
 	}
-	return src.BroadcastTxCommit(out)
 
+	return src.sendWithSequence(addr, func(accNum, seq uint64) ([]byte, error) {
+		return src.buildAndSignTxWithKey([]sdk.Msg{datagram}, keyName, accNum, seq)
+	})
 }
 
 // BuildAndSignTxWithKey allows the user to specify which relayer key will sign the message
 func (src *Chain) BuildAndSignTxWithKey(datagram []sdk.Msg, keyName string) ([]byte, error) {
 
 	// Fetch account and sequence numbers for the account
-	info, err := src.Keybase.Key(keyName)
+	addr, err := src.KeyAddress(keyName)
 	if err != nil {
 		return nil, err
 	}
@@ -35,16 +36,22 @@ func (src *Chain) BuildAndSignTxWithKey(datagram []sdk.Msg, keyName string) ([]b
 	done := src.UseSDKContext()
 	defer done()
 
-	acc, err := auth.NewAccountRetriever(src.Cdc, src).GetAccount(info.GetAddress())
+	acc, err := auth.NewAccountRetriever(src.Cdc, src).GetAccount(addr)
 	if err != nil {
 		return nil, err
 	}
 
-	return auth.NewTxBuilder(
-		auth.DefaultTxEncoder(src.Amino.Codec), acc.GetAccountNumber(),
-		acc.GetSequence(), src.Gas, src.GasAdjustment, false, src.ChainID,
-		src.Memo, sdk.NewCoins(), src.getGasPrices()).WithKeybase(src.Keybase).
-		BuildAndSign(info.GetName(), ckeys.DefaultKeyPass, datagram)
+	return src.buildAndSignTxWithKey(datagram, keyName, acc.GetAccountNumber(), acc.GetSequence())
+}
+
+func (src *Chain) buildAndSignTxWithKey(datagram []sdk.Msg, keyName string, accNum, seq uint64) ([]byte, error) {
+	done := src.UseSDKContext()
+	defer done()
+
+	return src.signTx(auth.NewTxBuilder(
+		auth.DefaultTxEncoder(src.Amino.Codec), accNum,
+		seq, src.Gas, src.GasAdjustment, false, src.ChainID,
+		src.Memo, sdk.NewCoins(), src.getGasPrices()), keyName, datagram)
 }
 
 // FaucetHandler listens for addresses
@@ -0,0 +1,237 @@
+package relayer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+const (
+	// defaultFeeDecay is how long gas prices stay raised before stepping back down
+	defaultFeeDecay = 10 * time.Minute
+	// maxFeeRetries is how many times a tx rejected for insufficient fees is resent
+	maxFeeRetries = 5
+)
+
+// FeePolicy lets a chain's gas prices rise when txs are rejected for insufficient
+// fees. Each rejection raises the prices by Step, up to Ceiling, and the prices
+// fall back by Step toward Floor for every Decay period without a rejection.
+// Floor defaults to the chain's gas-prices.
+type FeePolicy struct {
+	Floor   string `yaml:"floor,omitempty" json:"floor,omitempty"`
+	Ceiling string `yaml:"ceiling" json:"ceiling"`
+	Step    string `yaml:"step" json:"step"`
+	Decay   string `yaml:"decay,omitempty" json:"decay,omitempty"`
+
+	floor, ceiling, step sdk.DecCoins
+	decay                time.Duration
+}
+
+// init parses and validates the policy, using gasPrices as the default floor
+func (fp *FeePolicy) init(gasPrices string) (err error) {
+	floor := fp.Floor
+	if floor == "" {
+		floor = gasPrices
+	}
+	if fp.floor, err = sdk.ParseDecCoins(floor); err != nil {
+		return fmt.Errorf("invalid fee-policy floor (%s): %w", floor, err)
+	}
+	if fp.ceiling, err = sdk.ParseDecCoins(fp.Ceiling); err != nil {
+		return fmt.Errorf("invalid fee-policy ceiling (%s): %w", fp.Ceiling, err)
+	}
+	if fp.step, err = sdk.ParseDecCoins(fp.Step); err != nil {
+		return fmt.Errorf("invalid fee-policy step (%s): %w", fp.Step, err)
+	}
+
+	if fp.ceiling.Empty() || fp.step.Empty() {
+		return fmt.Errorf("fee-policy must set a ceiling and a step")
+	}
+	if _, neg := fp.ceiling.SafeSub(fp.floor); neg {
+		return fmt.Errorf("fee-policy ceiling (%s) is below its floor (%s)", fp.ceiling, fp.floor)
+	}
+	for _, c := range fp.step {
+		if !fp.ceiling.AmountOf(c.Denom).IsPositive() {
+			return fmt.Errorf("fee-policy ceiling (%s) has no %s to step toward", fp.ceiling, c.Denom)
+		}
+	}
+
+	fp.decay = defaultFeeDecay
+	if fp.Decay != "" {
+		if fp.decay, err = time.ParseDuration(fp.Decay); err != nil || fp.decay <= 0 {
+			return fmt.Errorf("invalid fee-policy decay (%s)", fp.Decay)
+		}
+	}
+	return nil
+}
+
+// stepPrices moves prices by n steps, up if n is positive and down otherwise,
+// keeping each denom between the floor and the ceiling
+func (fp *FeePolicy) stepPrices(prices sdk.DecCoins, n int64) sdk.DecCoins {
+	denoms := make(map[string]bool)
+	for _, coins := range []sdk.DecCoins{prices, fp.floor, fp.step} {
+		for _, c := range coins {
+			denoms[c.Denom] = true
+		}
+	}
+
+	var out sdk.DecCoins
+	for denom := range denoms {
+		amt := prices.AmountOf(denom).Add(fp.step.AmountOf(denom).MulInt64(n))
+		if floor := fp.floor.AmountOf(denom); amt.LT(floor) {
+			amt = floor
+		}
+		if ceiling := fp.ceiling.AmountOf(denom); amt.GT(ceiling) {
+			amt = ceiling
+		}
+		if amt.IsPositive() {
+			out = append(out, sdk.NewDecCoinFromDec(denom, amt))
+		}
+	}
+	return out.Sort()
+}
+
+// feeState holds the effective gas prices of a chain with a fee policy. It is
+// persisted in the relayer home so that restarts and status commands see it.
+type feeState struct {
+	sync.Mutex `json:"-"`
+
+	GasPrices string    `json:"gas-prices"`
+	Updated   time.Time `json:"updated"`
+
+	file string
+}
+
+func feesFile(home, chainID string) string {
+	return path.Join(home, "fees", fmt.Sprintf("%s.json", chainID))
+}
+
+// loadFeeState reads the fee state of a chain, starting at the policy's floor if none is stored
+func loadFeeState(file string, fp *FeePolicy) (*feeState, error) {
+	fs := &feeState{file: file}
+	bz, err := ioutil.ReadFile(file)
+	switch {
+	case os.IsNotExist(err):
+		fs.GasPrices, fs.Updated = fp.floor.String(), time.Now()
+		return fs, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if err = json.Unmarshal(bz, fs); err != nil {
+		return nil, fmt.Errorf("failed to read fee state from %s: %w", file, err)
+	}
+	// the policy may have changed since the state was stored
+	prices, err := sdk.ParseDecCoins(fs.GasPrices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fee state from %s: %w", file, err)
+	}
+	fs.GasPrices = fp.stepPrices(prices, 0).String()
+	return fs, nil
+}
+
+func (fs *feeState) save() error {
+	bz, err := json.Marshal(fs)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(path.Dir(fs.file), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fs.file, bz, 0600)
+}
+
+// EffectiveGasPrices returns the gas prices the chain's txs are currently sent with.
+// It only reads the fee state, the decay of the prices is stored when a tx is sent.
+func (src *Chain) EffectiveGasPrices() sdk.DecCoins {
+	if src.FeePolicy == nil || src.fees == nil {
+		gp, _ := sdk.ParseDecCoins(src.GasPrices)
+		return gp
+	}
+
+	src.fees.Lock()
+	defer src.fees.Unlock()
+	prices, _ := src.decayedGasPrices()
+	return prices
+}
+
+// decayedGasPrices returns the stored gas prices lowered by a step of the fee
+// policy for every decay interval passed since they were updated, and the number
+// of intervals passed. The fee state must be locked.
+func (src *Chain) decayedGasPrices() (sdk.DecCoins, int64) {
+	fs, fp := src.fees, src.FeePolicy
+	prices, _ := sdk.ParseDecCoins(fs.GasPrices)
+	n := int64(time.Since(fs.Updated) / fp.decay)
+	if n <= 0 {
+		return prices, 0
+	}
+	return fp.stepPrices(prices, -n), n
+}
+
+// sendGasPrices returns the gas prices to send a tx with, storing their decay
+func (src *Chain) sendGasPrices() sdk.DecCoins {
+	if src.FeePolicy == nil || src.fees == nil {
+		return src.EffectiveGasPrices()
+	}
+
+	fs, fp := src.fees, src.FeePolicy
+	fs.Lock()
+	defer fs.Unlock()
+
+	decayed, n := src.decayedGasPrices()
+	if n == 0 || decayed.String() == fs.GasPrices {
+		return decayed
+	}
+	fs.GasPrices = decayed.String()
+	fs.Updated = fs.Updated.Add(time.Duration(n) * fp.decay)
+	if err := fs.save(); err != nil {
+		src.Error(err)
+	}
+	src.Log(fmt.Sprintf("- [%s] gas prices decayed to %s", src.ChainID, decayed))
+	return decayed
+}
+
+// raiseGasPrices raises the chain's gas prices by a step of its fee policy after
+// a tx sent with the prices rejected was rejected for insufficient fees. Prices that
+// were already raised since are left alone. It returns false if the chain has no fee
+// policy or the prices are at the ceiling.
+func (src *Chain) raiseGasPrices(rejected sdk.DecCoins) bool {
+	if src.FeePolicy == nil || src.fees == nil {
+		return false
+	}
+
+	current := src.sendGasPrices()
+
+	fs, fp := src.fees, src.FeePolicy
+	fs.Lock()
+	defer fs.Unlock()
+
+	if current.String() != rejected.String() {
+		return true
+	}
+
+	raised := fp.stepPrices(current, 1)
+	if raised.String() == current.String() {
+		src.Log(fmt.Sprintf("- [%s] gas prices are at the fee-policy ceiling of %s", src.ChainID, fp.ceiling))
+		return false
+	}
+
+	fs.GasPrices, fs.Updated = raised.String(), time.Now()
+	if err := fs.save(); err != nil {
+		src.Error(err)
+	}
+	src.Log(fmt.Sprintf("- [%s] raised gas prices from %s to %s", src.ChainID, current, raised))
+	return true
+}
+
+// isInsufficientFee returns true if the tx was rejected because its fee was
+// below the node's minimum gas prices
+func isInsufficientFee(res sdk.TxResponse) bool {
+	return res.Codespace == sdkerrors.RootCodespace && res.Code == sdkerrors.ErrInsufficientFee.ABCICode()
+}
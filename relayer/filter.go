@@ -0,0 +1,200 @@
+package relayer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	xferTypes "github.com/cosmos/cosmos-sdk/x/ibc/20-transfer/types"
+)
+
+// errPacketFiltered is returned when a packet was not relayed because of the strategy's filter
+var errPacketFiltered = errors.New("packet filtered by strategy")
+
+// PacketFilter defines which packets a strategy relays. A packet is relayed if it
+// matches none of the Deny rules and, if any Allow rules are set, at least one of them.
+// Timeouts are always relayed so that funds are returned to the sender.
+type PacketFilter struct {
+	Allow []*PacketFilterRule `json:"allow,omitempty" yaml:"allow,omitempty"`
+	Deny  []*PacketFilterRule `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// PacketFilterRule matches a packet when all of its set fields match. PortID and ChannelID
+// are compared against the end the packet was sent from. Sender, Receiver, Denom and
+// MinAmount are compared against ICS20 transfer packet data, so a rule that sets any of
+// them never matches other packets. Denom matches either the full denom of the packet
+// or its base denom without the port/channel prefix.
+type PacketFilterRule struct {
+	PortID    string `json:"port-id,omitempty" yaml:"port-id,omitempty"`
+	ChannelID string `json:"channel-id,omitempty" yaml:"channel-id,omitempty"`
+	Sender    string `json:"sender,omitempty" yaml:"sender,omitempty"`
+	Receiver  string `json:"receiver,omitempty" yaml:"receiver,omitempty"`
+	Denom     string `json:"denom,omitempty" yaml:"denom,omitempty"`
+	MinAmount string `json:"min-amount,omitempty" yaml:"min-amount,omitempty"`
+}
+
+// Validate checks that the rules of the filter are well formed
+func (pf *PacketFilter) Validate() error {
+	if pf == nil {
+		return nil
+	}
+	for _, r := range append(pf.Allow, pf.Deny...) {
+		if r == nil {
+			return fmt.Errorf("packet filter contains an empty rule")
+		}
+		if r.MinAmount == "" {
+			continue
+		}
+		if amt, ok := sdk.NewIntFromString(r.MinAmount); !ok || amt.IsNegative() {
+			return fmt.Errorf("invalid min-amount (%s) in packet filter", r.MinAmount)
+		}
+	}
+	return nil
+}
+
+// Empty returns true if the filter has no rules and so allows all packets
+func (pf *PacketFilter) Empty() bool {
+	return pf == nil || (len(pf.Allow) == 0 && len(pf.Deny) == 0)
+}
+
+// Allows returns true if a packet sent from srcPort and srcChannel with the given
+// data should be relayed. A nil filter allows all packets.
+func (pf *PacketFilter) Allows(srcPort, srcChannel string, packetData []byte) bool {
+	if pf.Empty() {
+		return true
+	}
+
+	// packets that aren't ICS20 transfers only have their port and channel checked
+	var ftpd *xferTypes.FungibleTokenPacketData
+	var data xferTypes.FungibleTokenPacketData
+	if err := xferTypes.ModuleCdc.UnmarshalJSON(packetData, &data); err == nil {
+		ftpd = &data
+	}
+
+	for _, r := range pf.Deny {
+		if r.matches(srcPort, srcChannel, ftpd) {
+			return false
+		}
+	}
+
+	if len(pf.Allow) == 0 {
+		return true
+	}
+
+	for _, r := range pf.Allow {
+		if r.matches(srcPort, srcChannel, ftpd) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *PacketFilterRule) matches(srcPort, srcChannel string, ftpd *xferTypes.FungibleTokenPacketData) bool {
+	switch {
+	case r.PortID != "" && r.PortID != srcPort:
+		return false
+	case r.ChannelID != "" && r.ChannelID != srcChannel:
+		return false
+	case r.Sender == "" && r.Receiver == "" && r.Denom == "" && r.MinAmount == "":
+		return true
+	case ftpd == nil:
+		return false
+	case r.Sender != "" && r.Sender != ftpd.Sender:
+		return false
+	case r.Receiver != "" && r.Receiver != ftpd.Receiver:
+		return false
+	case r.Denom == "" && r.MinAmount == "":
+		return true
+	}
+
+	// one of the coins in the packet must satisfy both the denom and the amount
+	minAmt, _ := sdk.NewIntFromString(r.MinAmount)
+	for _, coin := range ftpd.Amount {
+		if r.Denom != "" && r.Denom != coin.Denom && r.Denom != baseDenom(coin.Denom) {
+			continue
+		}
+		if r.MinAmount != "" && coin.Amount.LT(minAmt) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// filterEvents returns the events without the send_packet and recv_packet events
+// of the packets the filter doesn't allow, so that no strategy relays them
+func (pf *PacketFilter) filterEvents(events map[string][]string) map[string][]string {
+	if pf.Empty() {
+		return events
+	}
+
+	var filtered map[string][]string
+	for _, typ := range []string{"send_packet", "recv_packet"} {
+		data := events[typ+".packet_data"]
+		srcPort, srcChan := events[typ+".packet_src_port"], events[typ+".packet_src_channel"]
+		if len(srcPort) != len(data) || len(srcChan) != len(data) {
+			continue
+		}
+
+		denied := make(map[int]bool)
+		for i, pd := range data {
+			if !pf.Allows(srcPort[i], srcChan[i], []byte(pd)) {
+				denied[i] = true
+			}
+		}
+		if len(denied) == 0 {
+			continue
+		}
+
+		if filtered == nil {
+			filtered = make(map[string][]string, len(events))
+			for k, v := range events {
+				filtered[k] = v
+			}
+		}
+		for k, v := range events {
+			if !strings.HasPrefix(k, typ+".") || len(v) != len(data) {
+				continue
+			}
+			var kept []string
+			for i, val := range v {
+				if !denied[i] {
+					kept = append(kept, val)
+				}
+			}
+			filtered[k] = kept
+		}
+	}
+
+	if filtered == nil {
+		return events
+	}
+	return filtered
+}
+
+// allowedSequences returns the sequences of the packets sent from src to dst that
+// the filter allows. The sequences of packets that can't be queried are kept, so
+// that the strategy reports them.
+func (pf *PacketFilter) allowedSequences(src, dst *Chain, sh *SyncHeaders, seqs []uint64) []uint64 {
+	if pf.Empty() {
+		return seqs
+	}
+
+	var allowed []uint64
+	for _, seq := range seqs {
+		if tx, err := packetTxFromQuery(src, sh, seq); err == nil {
+			_, _, err = relayPacketFromQueryResponse(src.PathEnd, dst.PathEnd, tx, sh, pf)
+			if errors.Is(err, errPacketFiltered) {
+				continue
+			}
+		}
+		allowed = append(allowed, seq)
+	}
+	return allowed
+}
+
+// baseDenom strips the {port}/{channel}/ prefix from an ICS20 voucher denom
+func baseDenom(denom string) string {
+	return denom[strings.LastIndex(denom, "/")+1:]
+}
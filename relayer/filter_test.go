@@ -0,0 +1,145 @@
+package relayer
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	xferTypes "github.com/cosmos/cosmos-sdk/x/ibc/20-transfer/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketFilterAllows(t *testing.T) {
+	transfer := xferTypes.NewFungibleTokenPacketData(
+		sdk.NewCoins(sdk.NewInt64Coin("transfer/ibczeroxfer/samoleans", 100)), "alice", "bob",
+	).GetBytes()
+	other := []byte(`{"foo":"bar"}`)
+
+	tests := []struct {
+		name   string
+		filter *PacketFilter
+		data   []byte
+		allows bool
+	}{
+		{"nil filter", nil, transfer, true},
+		{"empty filter", &PacketFilter{}, other, true},
+		{"deny port", &PacketFilter{Deny: []*PacketFilterRule{{PortID: "transfer"}}}, transfer, false},
+		{"deny other port", &PacketFilter{Deny: []*PacketFilterRule{{PortID: "other"}}}, transfer, true},
+		{"allow channel", &PacketFilter{Allow: []*PacketFilterRule{{ChannelID: "chan"}}}, other, true},
+		{"allow other channel", &PacketFilter{Allow: []*PacketFilterRule{{ChannelID: "other"}}}, transfer, false},
+		{"allow sender", &PacketFilter{Allow: []*PacketFilterRule{{Sender: "alice"}}}, transfer, true},
+		{"allow other sender", &PacketFilter{Allow: []*PacketFilterRule{{Sender: "bob"}}}, transfer, false},
+		{"allow receiver of non transfer", &PacketFilter{Allow: []*PacketFilterRule{{Receiver: "bob"}}}, other, false},
+		{"allow full denom", &PacketFilter{Allow: []*PacketFilterRule{{Denom: "transfer/ibczeroxfer/samoleans"}}}, transfer, true},
+		{"allow base denom", &PacketFilter{Allow: []*PacketFilterRule{{Denom: "samoleans"}}}, transfer, true},
+		{"allow other denom", &PacketFilter{Allow: []*PacketFilterRule{{Denom: "stake"}}}, transfer, false},
+		{"min amount met", &PacketFilter{Allow: []*PacketFilterRule{{Denom: "samoleans", MinAmount: "100"}}}, transfer, true},
+		{"min amount not met", &PacketFilter{Allow: []*PacketFilterRule{{Denom: "samoleans", MinAmount: "101"}}}, transfer, false},
+		{"deny wins over allow", &PacketFilter{
+			Allow: []*PacketFilterRule{{PortID: "transfer"}},
+			Deny:  []*PacketFilterRule{{Sender: "alice"}},
+		}, transfer, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.allows, tc.filter.Allows("transfer", "chan", tc.data))
+		})
+	}
+}
+
+func TestPacketFilterValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *PacketFilter
+		valid  bool
+	}{
+		{"nil filter", nil, true},
+		{"valid rules", &PacketFilter{
+			Allow: []*PacketFilterRule{{Denom: "samoleans", MinAmount: "10"}},
+			Deny:  []*PacketFilterRule{{Sender: "alice"}},
+		}, true},
+		{"empty rule", &PacketFilter{Allow: []*PacketFilterRule{nil}}, false},
+		{"invalid min amount", &PacketFilter{Deny: []*PacketFilterRule{{MinAmount: "ten"}}}, false},
+		{"negative min amount", &PacketFilter{Allow: []*PacketFilterRule{{MinAmount: "-1"}}}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.filter.Validate()
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestPathValidateRejectsFilterOnOrderedChannel(t *testing.T) {
+	newPath := func(order string, filter *PacketFilter) *Path {
+		end := func(chainID string) *PathEnd {
+			return &PathEnd{
+				ChainID: chainID, ClientID: "defaultclientid", ConnectionID: dcon,
+				ChannelID: dcha, PortID: dpor, Order: order,
+			}
+		}
+		return &Path{Src: end("ibc0"), Dst: end("ibc1"), Strategy: &StrategyCfg{Type: "naive", Filter: filter}}
+	}
+	filter := &PacketFilter{Deny: []*PacketFilterRule{{Sender: "alice"}}}
+
+	require.NoError(t, newPath("ORDERED", nil).Validate())
+	require.NoError(t, newPath("UNORDERED", filter).Validate())
+	require.Error(t, newPath("ORDERED", filter).Validate())
+}
+
+// eventsStrategy records the events handed to it
+type eventsStrategy struct {
+	NaiveStrategy
+	events []map[string][]string
+}
+
+func (es *eventsStrategy) HandleEvents(src, dst *Chain, sh *SyncHeaders, events map[string][]string) {
+	es.events = append(es.events, events)
+}
+
+func TestRelayPathFiltersEvents(t *testing.T) {
+	fromAlice := xferTypes.NewFungibleTokenPacketData(sdk.NewCoins(sdk.NewInt64Coin("stake", 1)), "alice", "bob")
+	fromCarol := xferTypes.NewFungibleTokenPacketData(sdk.NewCoins(sdk.NewInt64Coin("stake", 1)), "carol", "bob")
+	events := map[string][]string{
+		"tm.event":                       {"Tx"},
+		"send_packet.packet_data":        {string(fromAlice.GetBytes()), string(fromCarol.GetBytes())},
+		"send_packet.packet_sequence":    {"1", "2"},
+		"send_packet.packet_src_port":    {"transfer", "transfer"},
+		"send_packet.packet_src_channel": {"chan", "chan"},
+		"recv_packet.packet_data":        {string(fromAlice.GetBytes())},
+		"recv_packet.packet_sequence":    {"7"},
+		"recv_packet.packet_src_port":    {"transfer"},
+		"recv_packet.packet_src_channel": {"chan"},
+	}
+
+	strategy := &eventsStrategy{}
+	rp := &RelayPath{
+		Src:      &Chain{ChainID: "ibc0"},
+		Dst:      &Chain{ChainID: "ibc1"},
+		Strategy: strategy,
+		Filter:   &PacketFilter{Deny: []*PacketFilterRule{{Sender: "alice"}}},
+	}
+	rp.startQueues(nil)
+	rp.srcQueue.enqueue(events)
+	rp.stopQueues()
+
+	require.Len(t, strategy.events, 1)
+	got := strategy.events[0]
+	require.Equal(t, []string{"Tx"}, got["tm.event"])
+	require.Equal(t, []string{string(fromCarol.GetBytes())}, got["send_packet.packet_data"])
+	require.Equal(t, []string{"2"}, got["send_packet.packet_sequence"])
+	require.Empty(t, got["recv_packet.packet_data"])
+	require.Empty(t, got["recv_packet.packet_sequence"])
+	require.Equal(t, []string{"1", "2"}, events["send_packet.packet_sequence"], "events aren't modified")
+
+	rp.Filter = nil
+	rp.startQueues(nil)
+	rp.srcQueue.enqueue(events)
+	rp.stopQueues()
+	require.Equal(t, events, strategy.events[1], "nil filter relays all packets")
+}
@@ -0,0 +1,130 @@
+package relayer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	authclient "github.com/cosmos/cosmos-sdk/x/auth/client"
+)
+
+const (
+	// defaultGasAdjustment scales simulated gas when the chain sets no gas-adjustment
+	defaultGasAdjustment = 1.2
+	// maxOutOfGasRetries is how many times a tx that ran out of gas is resent
+	maxOutOfGasRetries = 2
+	// outOfGasMultiplier scales the gas a tx ran out of to get the limit it is resent with
+	outOfGasMultiplier = 1.5
+)
+
+func (src *Chain) getGasAdjustment() float64 {
+	if src.GasAdjustment > 0 {
+		return src.GasAdjustment
+	}
+	return defaultGasAdjustment
+}
+
+// gasLimit returns the gas limit to send msgs with. The msgs are simulated and the
+// simulated gas scaled by the gas adjustment is used if it is more than the chain's
+// fixed gas, or minGas if that is larger still, so that big batches of msgs don't
+// run out of gas. If the simulation fails the fixed gas is used, unless the chain
+// relies on simulation by setting a gas-adjustment or no gas.
+func (src *Chain) gasLimit(txBldr auth.TxBuilder, msgs []sdk.Msg, minGas uint64) (uint64, error) {
+	gas := src.Gas
+	if minGas > gas {
+		gas = minGas
+	}
+
+	txBytes, err := txBldr.BuildTxForSim(msgs)
+	if err != nil {
+		return 0, err
+	}
+
+	_, simulated, err := authclient.CalculateGas(src.QueryWithData, src.Amino.Codec, txBytes, src.getGasAdjustment())
+	switch {
+	case err != nil && (src.GasAdjustment > 0 || gas == 0):
+		return 0, fmt.Errorf("failed to simulate tx on chain %s: %w", src.ChainID, err)
+	case err != nil:
+		if src.debug {
+			src.Log(fmt.Sprintf("- [%s] failed to simulate tx, sending with %d gas: %s", src.ChainID, gas, err))
+		}
+		return gas, nil
+	case simulated > gas:
+		return simulated, nil
+	default:
+		return gas, nil
+	}
+}
+
+// sendMsgs signs msgs with the named key of addr and sends them. A tx that runs
+// out of gas is rebuilt and resent with a higher gas limit, and one rejected for
+// insufficient fees is resent with the higher gas prices of the chain's fee policy.
+func (src *Chain) sendMsgs(msgs []sdk.Msg, keyName string, addr sdk.AccAddress) (res sdk.TxResponse, err error) {
+	var (
+		minGas                 uint64
+		gasRetries, feeRetries int
+	)
+	for {
+		prices := src.getGasPrices()
+		res, err = src.sendWithSequence(addr, func(accNum, seq uint64) ([]byte, error) {
+			return src.buildAndSignTx(msgs, keyName, accNum, seq, minGas)
+		})
+		if err != nil {
+			return res, err
+		}
+		src.logGasUsage(res, msgs)
+
+		switch {
+		case isOutOfGas(res) && gasRetries < maxOutOfGasRetries:
+			gasRetries++
+			used := res.GasUsed
+			if res.GasWanted > used {
+				used = res.GasWanted
+			}
+			minGas = uint64(float64(used) * outOfGasMultiplier)
+			src.Log(fmt.Sprintf("- [%s] tx %s ran out of gas (%d wanted), resending with %d gas",
+				src.ChainID, res.TxHash, res.GasWanted, minGas))
+		case isInsufficientFee(res) && feeRetries < maxFeeRetries && src.raiseGasPrices(prices):
+			feeRetries++
+		default:
+			return res, nil
+		}
+	}
+}
+
+// logGasUsage logs the gas used by a tx against the gas it wanted. When running
+// with --debug the number of msgs of each type in the tx is logged as well.
+func (src *Chain) logGasUsage(res sdk.TxResponse, msgs []sdk.Msg) {
+	if res.GasWanted <= 0 {
+		return
+	}
+	msg := fmt.Sprintf("- [%s] tx %s used %d of %d gas (%d%%)",
+		src.ChainID, res.TxHash, res.GasUsed, res.GasWanted, res.GasUsed*100/res.GasWanted)
+	if src.debug {
+		msg += fmt.Sprintf(" with msgs(%s)", getMsgTypeCounts(msgs))
+	}
+	src.Log(msg)
+}
+
+// getMsgTypeCounts returns the number of msgs of each type, sorted by type
+func getMsgTypeCounts(msgs []sdk.Msg) string {
+	counts := make(map[string]int)
+	for _, msg := range msgs {
+		counts[msg.Type()]++
+	}
+
+	out := make([]string, 0, len(counts))
+	for typ, n := range counts {
+		out = append(out, fmt.Sprintf("%s:%d", typ, n))
+	}
+	sort.Strings(out)
+	return strings.Join(out, ",")
+}
+
+// isOutOfGas returns true if the tx ran out of gas
+func isOutOfGas(res sdk.TxResponse) bool {
+	return res.Codespace == sdkerrors.RootCodespace && res.Code == sdkerrors.ErrOutOfGas.ABCICode()
+}
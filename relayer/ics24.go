@@ -56,6 +56,16 @@ func (c *Chain) SetPath(p *PathEnd) error {
 	return nil
 }
 
+// WithPath returns a copy of the chain with the given path set. The copy shares its
+// rpc client and keybase with the original, so one chain can relay over many paths.
+func (c *Chain) WithPath(p *PathEnd) (*Chain, error) {
+	out := *c
+	if err := out.SetPath(p); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 // AddPath takes the elements of a path and validates then, setting that path to the chain
 func (c *Chain) AddPath(clientID, connectionID, channelID, port, order string) error {
 	return c.SetPath(&PathEnd{ChainID: c.ChainID, ClientID: clientID, ConnectionID: connectionID, ChannelID: channelID, PortID: port, Order: order})
@@ -0,0 +1,239 @@
+package relayer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	chanTypes "github.com/cosmos/cosmos-sdk/x/ibc/04-channel/types"
+	tmclient "github.com/cosmos/cosmos-sdk/x/ibc/07-tendermint/types"
+)
+
+// keyBalanceInterval is how often the balances of a chain's pooled keys are checked
+const keyBalanceInterval = time.Minute
+
+// keyPool spreads the relay txs sent to a chain over its signing keys. Each key
+// has its own account sequence, so txs signed by different keys can be in the
+// same block. Keys with less than the chain's min-key-balance are skipped.
+type keyPool struct {
+	sync.Mutex
+	keys []*pooledKey
+
+	// held while the keys' addresses are looked up, which happens once
+	resolve  sync.Mutex
+	resolved bool
+	// set while a goroutine queries the keys' balances
+	checking bool
+}
+
+type pooledKey struct {
+	name     string
+	address  sdk.AccAddress
+	inFlight int
+
+	// set when the key's balance is below the minimum or a tx failed for lack of funds
+	low     bool
+	checked time.Time
+}
+
+func newKeyPool(names []string) *keyPool {
+	kp := &keyPool{}
+	for _, name := range names {
+		kp.keys = append(kp.keys, &pooledKey{name: name})
+	}
+	return kp
+}
+
+// KeyNames returns the names of the keys the chain signs relay txs with. The
+// chain's Key is always first.
+func (src *Chain) KeyNames() []string {
+	names := []string{src.Key}
+	seen := map[string]bool{src.Key: true}
+	for _, name := range src.Keys {
+		if !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	return names
+}
+
+func (src *Chain) getMinKeyBalance() sdk.Coins {
+	coins, _ := sdk.ParseCoins(src.MinKeyBalance)
+	return coins
+}
+
+// acquireKey returns the pooled key with the fewest txs in flight, preferring keys
+// with enough balance. It must be released with releaseKey once the tx is done.
+// The keys' addresses and balances are queried without holding the pool's lock,
+// so concurrent sends aren't held up by the signer or the chain.
+func (src *Chain) acquireKey() (*pooledKey, error) {
+	if err := src.resolveKeyAddresses(); err != nil {
+		return nil, err
+	}
+	src.checkKeyBalances()
+
+	kp := src.pool
+	kp.Lock()
+	defer kp.Unlock()
+
+	var best *pooledKey
+	for _, k := range kp.keys {
+		switch {
+		case best == nil:
+			best = k
+		case best.low && !k.low:
+			best = k
+		case best.low == k.low && k.inFlight < best.inFlight:
+			best = k
+		}
+	}
+
+	if best.low {
+		src.Log(fmt.Sprintf("- [%s] all relayer keys are below the min-key-balance of %s, sending with %s",
+			src.ChainID, src.MinKeyBalance, best.name))
+	}
+	best.inFlight++
+	return best, nil
+}
+
+// resolveKeyAddresses looks up the addresses of the pooled keys from the chain's
+// signer the first time the pool is used
+func (src *Chain) resolveKeyAddresses() error {
+	kp := src.pool
+	kp.Lock()
+	resolved := kp.resolved
+	kp.Unlock()
+	if resolved {
+		return nil
+	}
+
+	kp.resolve.Lock()
+	defer kp.resolve.Unlock()
+	addrs := make([]sdk.AccAddress, len(kp.keys))
+	for i, k := range kp.keys {
+		addr, err := src.KeyAddress(k.name)
+		if err != nil {
+			return fmt.Errorf("failed to get address of key %s on chain %s: %w", k.name, src.ChainID, err)
+		}
+		addrs[i] = addr
+	}
+
+	kp.Lock()
+	defer kp.Unlock()
+	for i, k := range kp.keys {
+		k.address = addrs[i]
+	}
+	kp.resolved = true
+	return nil
+}
+
+// releaseKey returns a key to the pool, marking it low on funds if the tx
+// was rejected because the key couldn't pay for it
+func (src *Chain) releaseKey(k *pooledKey, res sdk.TxResponse) {
+	src.pool.Lock()
+	defer src.pool.Unlock()
+
+	k.inFlight--
+	if isInsufficientFunds(res) {
+		src.Log(fmt.Sprintf("- [%s] key %s has insufficient funds", src.ChainID, k.name))
+		k.low, k.checked = true, time.Now()
+	}
+}
+
+// checkKeyBalances marks the keys whose balance is below the chain's minimum as low.
+// Balances are queried at most once every keyBalanceInterval, by one goroutine at a
+// time, while other sends go ahead with the last known balances.
+func (src *Chain) checkKeyBalances() {
+	minBalance := src.getMinKeyBalance()
+	if minBalance.Empty() {
+		return
+	}
+
+	kp := src.pool
+	kp.Lock()
+	if kp.checking {
+		kp.Unlock()
+		return
+	}
+	var due []*pooledKey
+	for _, k := range kp.keys {
+		if time.Since(k.checked) >= keyBalanceInterval {
+			k.checked = time.Now()
+			due = append(due, k)
+		}
+	}
+	kp.checking = len(due) > 0
+	kp.Unlock()
+
+	for _, k := range due {
+		coins, err := src.QueryBalance(k.name)
+		if err != nil {
+			// keep the last known state of the key rather than dropping it from the pool
+			src.Error(err)
+			continue
+		}
+		kp.Lock()
+		k.low = !coins.IsAllGTE(minBalance)
+		kp.Unlock()
+	}
+
+	kp.Lock()
+	kp.checking = false
+	kp.Unlock()
+}
+
+// sendRelayMsgs signs and sends msgs with a key from the chain's key pool. Msgs
+// the relayer doesn't know how to re-sign are sent with the chain's Key.
+func (src *Chain) sendRelayMsgs(msgs []sdk.Msg) (res sdk.TxResponse, err error) {
+	if src.pool == nil || len(src.pool.keys) < 2 {
+		return src.SendMsgs(msgs)
+	}
+	for _, msg := range msgs {
+		if _, ok := withSigner(msg, nil); !ok {
+			return src.SendMsgs(msgs)
+		}
+	}
+
+	k, err := src.acquireKey()
+	if err != nil {
+		return res, err
+	}
+	defer func() { src.releaseKey(k, res) }()
+
+	signed := make([]sdk.Msg, len(msgs))
+	for i, msg := range msgs {
+		signed[i], _ = withSigner(msg, k.address)
+	}
+
+	return src.sendMsgs(signed, k.name, k.address)
+}
+
+// withSigner returns a copy of a relay msg signed by addr, and false if the msg
+// type isn't sent by relay strategies
+func withSigner(msg sdk.Msg, addr sdk.AccAddress) (sdk.Msg, bool) {
+	switch m := msg.(type) {
+	case tmclient.MsgUpdateClient:
+		m.Signer = addr
+		return m, true
+	case chanTypes.MsgPacket:
+		m.Signer = addr
+		return m, true
+	case chanTypes.MsgTimeout:
+		m.Signer = addr
+		return m, true
+	case chanTypes.MsgAcknowledgement:
+		m.Signer = addr
+		return m, true
+	default:
+		return msg, false
+	}
+}
+
+// isInsufficientFunds returns true if the tx was rejected because its signer
+// couldn't pay the fees
+func isInsufficientFunds(res sdk.TxResponse) bool {
+	return res.Codespace == sdkerrors.RootCodespace && res.Code == sdkerrors.ErrInsufficientFunds.ABCICode()
+}
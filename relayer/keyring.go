@@ -0,0 +1,106 @@
+package relayer
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/client/input"
+	keys "github.com/cosmos/cosmos-sdk/crypto/keyring"
+)
+
+const (
+	// KeyringPassphraseEnv is the environment variable the keyring passphrase is read from
+	KeyringPassphraseEnv = "RLY_KEYRING_PASSPHRASE"
+
+	// defaultKeyringBackend is used by chains when no backend is configured
+	defaultKeyringBackend = keys.BackendTest
+)
+
+// validateKeyringBackend checks that the keyring backend is supported by the relayer
+func validateKeyringBackend(backend string) error {
+	switch backend {
+	case keys.BackendFile, keys.BackendOS, keys.BackendTest, keys.BackendMemory:
+		return nil
+	default:
+		return fmt.Errorf("invalid keyring-backend (%s), must be one of file, os, test or memory", backend)
+	}
+}
+
+// SetKeyringDefaults sets the keyring backend used when the chain doesn't configure
+// its own, and the file the keyring passphrase is read from. It must be called
+// before Init to take effect.
+func (src *Chain) SetKeyringDefaults(backend, passphraseFile string) {
+	src.defaultKeyringBackend = backend
+	src.keyringPassphraseFile = passphraseFile
+}
+
+// GetKeyringBackend returns the keyring backend the chain's keys are stored in
+func (src *Chain) GetKeyringBackend() string {
+	switch {
+	case src.KeyringBackend != "":
+		return src.KeyringBackend
+	case src.defaultKeyringBackend != "":
+		return src.defaultKeyringBackend
+	default:
+		return defaultKeyringBackend
+	}
+}
+
+// keyringPassphrase returns the passphrase set in the environment or the passphrase
+// file, and false if neither is set
+func (src *Chain) keyringPassphrase() (string, bool, error) {
+	if pass, ok := os.LookupEnv(KeyringPassphraseEnv); ok {
+		return pass, true, nil
+	}
+	if src.keyringPassphraseFile == "" {
+		return "", false, nil
+	}
+	bz, err := ioutil.ReadFile(src.keyringPassphraseFile)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read keyring passphrase file: %w", err)
+	}
+	return strings.TrimRight(string(bz), "\r\n"), true, nil
+}
+
+// newKeyring opens the chain's keyring. A passphrase from the environment or the
+// passphrase file is given to the keyring's passphrase prompt as its input,
+// otherwise the user is prompted on stdin.
+// NOTE: the sdk's prompt reads from the terminal instead of its input when stdin
+// is one, so a configured passphrase is only used when stdin isn't a terminal.
+func (src *Chain) newKeyring(backend, dir string) (keys.Keyring, error) {
+	pass, ok, err := src.keyringPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return keys.New(src.ChainID, backend, dir, os.Stdin)
+	}
+	return keys.New(src.ChainID, backend, dir, &repeatReader{line: []byte(pass + "\n")})
+}
+
+// KeyExportPassphrase returns the passphrase to encrypt exported keys with. It is
+// the keyring passphrase when one is set in the environment or passphrase file,
+// and is prompted for otherwise.
+func (src *Chain) KeyExportPassphrase() (string, error) {
+	pass, ok, err := src.keyringPassphrase()
+	if err != nil || ok {
+		return pass, err
+	}
+	return input.GetPassword("Enter passphrase to encrypt the exported key:", bufio.NewReader(os.Stdin))
+}
+
+// repeatReader endlessly repeats a line. Each read ends at the end of the line,
+// so a new buffered reader wrapping it starts at the beginning of the line.
+type repeatReader struct {
+	line []byte
+	pos  int
+}
+
+func (r *repeatReader) Read(p []byte) (n int, err error) {
+	n = copy(p, r.line[r.pos:])
+	r.pos = (r.pos + n) % len(r.line)
+	return n, nil
+}
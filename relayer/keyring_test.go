@@ -0,0 +1,24 @@
+package relayer
+
+import (
+	"bufio"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client/input"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepeatReaderPrompts(t *testing.T) {
+	r := &repeatReader{line: []byte("correct horse\n")}
+
+	// the keyring prompt wraps its input in a new buffered reader on every prompt,
+	// and reads the passphrase twice when it is first set
+	for i := 0; i < 3; i++ {
+		buf := bufio.NewReader(r)
+		for j := 0; j < 2; j++ {
+			pass, err := input.GetPassword("Enter keyring passphrase:", buf)
+			require.NoError(t, err)
+			require.Equal(t, "correct horse", pass)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package relayer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	keys "github.com/cosmos/cosmos-sdk/crypto/keyring"
+)
+
+// DefaultCoinType is the coin type keys are derived with when a chain doesn't set one
+const DefaultCoinType = 118
+
+// hdPathsMutex guards the files that record the derivation paths of keys
+var hdPathsMutex sync.Mutex
+
+// GetCoinType returns the coin type the chain's keys are derived with
+func (src *Chain) GetCoinType() uint32 {
+	if src.CoinType == nil {
+		return DefaultCoinType
+	}
+	return *src.CoinType
+}
+
+// HDPath returns the BIP44 derivation path for the given account and index
+// with the chain's coin type
+func (src *Chain) HDPath(account, index uint32) string {
+	return hd.CreateHDPath(src.GetCoinType(), account, index).String()
+}
+
+// RestoreKey derives a key from the mnemonic at the given account and index, stores it in
+// the chain's keyring under name and records its derivation path
+func (src *Chain) RestoreKey(name, mnemonic string, account, index uint32, algo string) (keys.Info, error) {
+	signAlgo, err := keys.NewSigningAlgoFromString(algo)
+	if err != nil {
+		return nil, err
+	}
+
+	hdPath := src.HDPath(account, index)
+	info, err := src.Keybase.NewAccount(name, mnemonic, "", hdPath, signAlgo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = src.setKeyHDPath(name, hdPath); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// DeleteKey removes the key from the chain's keyring along with its recorded derivation path
+func (src *Chain) DeleteKey(name string) error {
+	if err := src.Keybase.Delete(name); err != nil {
+		return err
+	}
+	return src.setKeyHDPath(name, "")
+}
+
+// KeyHDPath returns the derivation path the key was created with. Keys created
+// before paths were recorded were derived with the default path.
+func (src *Chain) KeyHDPath(name string) (string, error) {
+	hdPathsMutex.Lock()
+	defer hdPathsMutex.Unlock()
+
+	paths, err := src.readKeyHDPaths()
+	if err != nil {
+		return "", err
+	}
+	if p, ok := paths[name]; ok {
+		return p, nil
+	}
+	return hd.CreateHDPath(DefaultCoinType, 0, 0).String(), nil
+}
+
+// setKeyHDPath records the derivation path of a key, removing it if hdPath is empty
+func (src *Chain) setKeyHDPath(name, hdPath string) error {
+	hdPathsMutex.Lock()
+	defer hdPathsMutex.Unlock()
+
+	paths, err := src.readKeyHDPaths()
+	if err != nil {
+		return err
+	}
+
+	if hdPath == "" {
+		delete(paths, name)
+	} else {
+		paths[name] = hdPath
+	}
+
+	out, err := json.Marshal(paths)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(keysDir(src.HomePath, src.ChainID), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(hdPathsFile(src.HomePath, src.ChainID), out, 0600)
+}
+
+func (src *Chain) readKeyHDPaths() (map[string]string, error) {
+	paths := make(map[string]string)
+	bz, err := ioutil.ReadFile(hdPathsFile(src.HomePath, src.ChainID))
+	switch {
+	case os.IsNotExist(err):
+		return paths, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if err = json.Unmarshal(bz, &paths); err != nil {
+		return nil, fmt.Errorf("failed to read key derivation paths for chain %s: %w", src.ChainID, err)
+	}
+	return paths, nil
+}
+
+// hdPathsFile returns the path to the file recording the derivation paths of a chain's
+// keys, which the keyring doesn't store for local keys
+func hdPathsFile(home, chainID string) string {
+	return path.Join(keysDir(home, chainID), "hd-paths.json")
+}
@@ -0,0 +1,427 @@
+package relayer
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// RelayPath is a single path run by the relayer. The Src and Dst chains
+// must have their PathEnds set for the path being relayed over.
+type RelayPath struct {
+	Src      *Chain
+	Dst      *Chain
+	Strategy Strategy
+	Ordered  bool
+
+	// Filter decides which of the path's packets are relayed, whatever the strategy.
+	// Nil relays all packets.
+	Filter *PacketFilter
+
+	// Workers is the number of events handled concurrently in each direction
+	// of the path and QueueSize the number of events that can wait to be handled.
+	// Zero values take the defaults. Events from a chain wait for room in a full
+	// queue unless DropEvents is set, in which case they are dropped and the path's
+	// unrelayed packets are relayed once its queues have drained.
+	Workers    int
+	QueueSize  int
+	DropEvents bool
+
+	// ClientRefresh is the fraction of a client's trusting period after which
+	// the client is updated even without packets to relay. Zero takes the default.
+	ClientRefresh float64
+
+	// OnMisbehaviour is called when a client of the path is found to have accepted
+	// a conflicting header, after evidence of it was submitted or failed to be
+	OnMisbehaviour func(Misbehaviour) error
+
+	srcQueue, dstQueue *eventQueue
+	// set when events were dropped from a full queue, so the path's unrelayed
+	// packets are relayed once the queues have drained
+	catchUp int32
+}
+
+func (rp *RelayPath) String() string {
+	return fmt.Sprintf("[%s]:{%s} <-> [%s]:{%s}",
+		rp.Src.ChainID, rp.Src.PathEnd.PortID, rp.Dst.ChainID, rp.Dst.PathEnd.PortID)
+}
+
+// QueueDepth returns the number of events from the src and dst chains that
+// are waiting to be handled by the path's strategy
+func (rp *RelayPath) QueueDepth() (src, dst int) {
+	return rp.srcQueue.depth(), rp.dstQueue.depth()
+}
+
+// DroppedEvents returns the number of events from the src and dst chains that
+// were dropped because the path's queues were full
+func (rp *RelayPath) DroppedEvents() (src, dst uint64) {
+	return rp.srcQueue.droppedEvents(), rp.dstQueue.droppedEvents()
+}
+
+// startQueues starts the workers that pass events from each chain to the strategy
+func (rp *RelayPath) startQueues(sh *SyncHeaders) {
+	workers, size := rp.Workers, rp.QueueSize
+	if workers <= 0 {
+		workers = DefaultEventWorkers
+	}
+	if size <= 0 {
+		size = DefaultEventQueueSize
+	}
+
+	// events emitted by one chain are handled with the counterparty as the src
+	rp.srcQueue = newEventQueue(size, workers, rp.DropEvents, rp.queueFull(rp.Src, size),
+		func(events map[string][]string) {
+			rp.Strategy.HandleEvents(rp.Dst, rp.Src, sh, rp.Filter.filterEvents(events))
+			rp.catchUpDropped(sh)
+		})
+	rp.dstQueue = newEventQueue(size, workers, rp.DropEvents, rp.queueFull(rp.Dst, size),
+		func(events map[string][]string) {
+			rp.Strategy.HandleEvents(rp.Src, rp.Dst, sh, rp.Filter.filterEvents(events))
+			rp.catchUpDropped(sh)
+		})
+}
+
+// queueFull returns the func called when the path's queue for events from c fills
+// up. It logs the full queue and, if events are dropped, has the path's unrelayed
+// packets relayed once its queues have drained.
+func (rp *RelayPath) queueFull(c *Chain, size int) func(waiting int) {
+	return func(waiting int) {
+		if !rp.DropEvents {
+			c.Log(fmt.Sprintf("- %s queue for events from %s is full (%d), %d event(s) waiting for room",
+				rp, c.ChainID, size, waiting))
+			return
+		}
+		atomic.StoreInt32(&rp.catchUp, 1)
+		c.Error(fmt.Errorf("%s queue for events from %s is full (%d), dropping events until it has drained",
+			rp, c.ChainID, size))
+	}
+}
+
+// catchUpDropped relays the path's unrelayed packets if events were dropped from
+// its queues and both have drained since
+func (rp *RelayPath) catchUpDropped(sh *SyncHeaders) {
+	if rp.srcQueue.depth() > 0 || rp.dstQueue.depth() > 0 || !atomic.CompareAndSwapInt32(&rp.catchUp, 1, 0) {
+		return
+	}
+	rp.Src.Log(fmt.Sprintf("- %s queues drained, relaying packets of dropped events", rp))
+	if err := rp.RelayUnrelayed(sh); err != nil {
+		rp.Src.Error(err)
+	}
+}
+
+// stopQueues waits for the queued events to be handled and stops the workers
+func (rp *RelayPath) stopQueues() {
+	rp.srcQueue.stop()
+	rp.dstQueue.stop()
+}
+
+// RelayUnrelayed fetches any unrelayed sequences on the path and relays the ones
+// the path's filter allows depending on the channel order
+func (rp *RelayPath) RelayUnrelayed(sh *SyncHeaders) (err error) {
+	var sp *RelaySequences
+	if rp.Ordered {
+		sp, err = rp.Strategy.UnrelayedSequencesOrdered(rp.Src, rp.Dst, sh)
+	} else {
+		sp, err = rp.Strategy.UnrelayedSequencesUnordered(rp.Src, rp.Dst, sh)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	sp.Src = rp.Filter.allowedSequences(rp.Src, rp.Dst, sh, sp.Src)
+	sp.Dst = rp.Filter.allowedSequences(rp.Dst, rp.Src, sh, sp.Dst)
+
+	if rp.Ordered {
+		return rp.Strategy.RelayPacketsOrderedChan(rp.Src, rp.Dst, sp, sh)
+	}
+	return rp.Strategy.RelayPacketsUnorderedChan(rp.Src, rp.Dst, sp, sh)
+}
+
+const (
+	// eventTimeout is how long a chain's listener waits for a block event before
+	// checking whether its subscriptions are still alive
+	eventTimeout = time.Minute
+	// maxReconnectBackoff caps the delay between attempts to reconnect a listener
+	maxReconnectBackoff = time.Minute
+)
+
+// chainListener holds the single set of event subscriptions for a chain
+// and hands the events to every path that relays over that chain
+type chainListener struct {
+	chain *Chain
+	paths []*RelayPath
+
+	// client is used only for the event subscriptions, so that it can be replaced
+	// on reconnect without touching the client shared by the chain's paths
+	client                rpcclient.Client
+	txEvents, blockEvents <-chan ctypes.ResultEvent
+
+	// addr is the rpc endpoint the subscriptions were made on
+	addr string
+
+	lastEvent  time.Time
+	lastHeight int64
+}
+
+// subscribe connects a new rpc client to the chain's active rpc endpoint and
+// subscribes to tx and block events
+func (cl *chainListener) subscribe() (err error) {
+	addr := cl.chain.ActiveRPCAddr()
+	client, err := newRPCClient(addr, cl.chain.timeout)
+	if err != nil {
+		return err
+	}
+	if err = client.Start(); err != nil {
+		return err
+	}
+
+	// Subscibe to txEvents from the chain
+	txEvents, txCancel, err := cl.chain.subscribe(client, txEvents)
+	if err != nil {
+		_ = client.Stop()
+		return err
+	}
+	defer txCancel()
+	cl.chain.Log(fmt.Sprintf("- listening to tx events from %s...", cl.chain.ChainID))
+
+	// Subscibe to blockEvents from the chain
+	blockEvents, blockCancel, err := cl.chain.subscribe(client, blEvents)
+	if err != nil {
+		_ = client.Stop()
+		return err
+	}
+	defer blockCancel()
+	cl.chain.Log(fmt.Sprintf("- listening to block events from %s...", cl.chain.ChainID))
+
+	cl.client, cl.txEvents, cl.blockEvents = client, txEvents, blockEvents
+	cl.addr = addr
+	cl.lastEvent = time.Now()
+	return nil
+}
+
+// unsubscribe stops the client used for the subscriptions
+func (cl *chainListener) unsubscribe() {
+	if cl.client != nil {
+		_ = cl.client.Stop()
+		cl.client = nil
+	}
+}
+
+// alive reports whether the subscriptions are still delivering events. If no block
+// event has arrived for a while the node is asked for its latest height, so a chain
+// that simply hasn't produced a block isn't mistaken for a dead connection.
+func (cl *chainListener) alive() bool {
+	if time.Since(cl.lastEvent) < eventTimeout {
+		return true
+	}
+	height, err := cl.chain.QueryLatestHeight()
+	return err == nil && height <= cl.lastHeight
+}
+
+// reconnect replaces the subscriptions, backing off between failed attempts
+// until it succeeds or doneChan is closed. It returns false if doneChan was closed.
+func (cl *chainListener) reconnect(doneChan <-chan struct{}) bool {
+	cl.unsubscribe()
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		cl.chain.Log(fmt.Sprintf("- reconnecting to %s (attempt %d)...", cl.chain.ChainID, attempt))
+		err := cl.subscribe()
+		if err == nil {
+			return true
+		}
+		cl.chain.Error(fmt.Errorf("failed to reconnect to %s, retrying in %s: %w", cl.chain.ChainID, backoff, err))
+
+		select {
+		case <-time.After(backoff):
+		case <-doneChan:
+			return false
+		}
+		if backoff *= 2; backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
+
+// catchUp updates the chain's header and relays any packets on the listener's
+// paths that were missed while the listener was disconnected
+func (cl *chainListener) catchUp(sh *SyncHeaders) {
+	if err := sh.Update(cl.chain); err != nil {
+		cl.chain.Error(err)
+	}
+	for _, rp := range cl.paths {
+		if err := rp.RelayUnrelayed(sh); err != nil {
+			cl.chain.Error(err)
+		}
+	}
+}
+
+// listen hands events to the paths until doneChan is closed, reconnecting
+// whenever the subscriptions stop delivering events
+func (cl *chainListener) listen(doneChan <-chan struct{}, wg *sync.WaitGroup, sh *SyncHeaders) {
+	defer wg.Done()
+	defer cl.unsubscribe()
+
+	cl.lastHeight = int64(sh.GetHeight(cl.chain.ChainID))
+	ticker := time.NewTicker(eventTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-cl.txEvents:
+			cl.chain.logTx(msg.Events)
+			cl.handleEvents(msg.Events)
+		case msg := <-cl.blockEvents:
+			cl.lastEvent = time.Now()
+			if block, ok := msg.Data.(tmtypes.EventDataNewBlock); ok && block.Block != nil {
+				cl.lastHeight = block.Block.Height
+			}
+			// TODO: Add debug block logging here
+			if cl.chain.debug {
+				cl.logQueueDepths(true)
+			}
+			// NOTE: the headers are updated once per chain and shared by all paths
+			if err := sh.Update(cl.chain); err != nil {
+				cl.chain.Error(err)
+			}
+			cl.handleEvents(msg.Events)
+		case <-ticker.C:
+			cl.logQueueDepths(false)
+			switch addr := cl.chain.ActiveRPCAddr(); {
+			case addr != cl.addr:
+				cl.chain.Log(fmt.Sprintf("- [%s] rpc endpoint changed from %s to %s, moving subscriptions",
+					cl.chain.ChainID, cl.addr, addr))
+			case cl.alive():
+				continue
+			default:
+				cl.chain.Error(fmt.Errorf("no events received from %s since %s, the connection is presumed lost",
+					cl.chain.ChainID, cl.lastEvent.Format(time.RFC3339)))
+			}
+			if !cl.reconnect(doneChan) {
+				return
+			}
+			cl.catchUp(sh)
+		case <-doneChan:
+			return
+		}
+	}
+}
+
+// handleEvents queues events emitted by the listener's chain on each path that
+// relays over the chain. It never blocks, as each queue waits for room on its own
+// feeder, so a busy path doesn't hold up the other paths on the chain or the
+// updates of its headers.
+func (cl *chainListener) handleEvents(events map[string][]string) {
+	for _, rp := range cl.paths {
+		if rp.Src.ChainID == cl.chain.ChainID {
+			rp.srcQueue.enqueue(events)
+		}
+		if rp.Dst.ChainID == cl.chain.ChainID {
+			rp.dstQueue.enqueue(events)
+		}
+	}
+}
+
+// logQueueDepths logs the number of events waiting on and dropped from each of
+// the listener's paths, or only on the paths with queued events unless all is set
+func (cl *chainListener) logQueueDepths(all bool) {
+	for _, rp := range cl.paths {
+		src, dst := rp.QueueDepth()
+		if !all && src == 0 && dst == 0 {
+			continue
+		}
+		srcDropped, dstDropped := rp.DroppedEvents()
+		cl.chain.Log(fmt.Sprintf("- %s queued events: %s(%d) %s(%d), dropped events: %s(%d) %s(%d)",
+			rp, rp.Src.ChainID, src, rp.Dst.ChainID, dst, rp.Src.ChainID, srcDropped, rp.Dst.ChainID, dstDropped))
+	}
+}
+
+// RunStrategies runs the strategy of each of the passed paths in a single process.
+// Event subscriptions and SyncHeaders are shared by all paths that relay over
+// the same chain. The returned func stops relaying on all of the paths.
+func RunStrategies(paths ...*RelayPath) (func(), error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths to relay over")
+	}
+
+	// Group the paths by the chains they relay over
+	var (
+		chains    []*Chain
+		listeners = make(map[string]*chainListener)
+	)
+	for _, rp := range paths {
+		for _, c := range []*Chain{rp.Src, rp.Dst} {
+			cl, ok := listeners[c.ChainID]
+			if !ok {
+				cl = &chainListener{chain: c}
+				listeners[c.ChainID] = cl
+				chains = append(chains, c)
+			}
+			if len(cl.paths) == 0 || cl.paths[len(cl.paths)-1] != rp {
+				cl.paths = append(cl.paths, rp)
+			}
+		}
+	}
+
+	// Fetch latest headers for each chain and store them in sync headers
+	sh, err := NewSyncHeaders(chains...)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		once     sync.Once
+		wg       sync.WaitGroup
+		doneChan = make(chan struct{})
+	)
+
+	// Start the workers that handle the events for each path
+	for _, rp := range paths {
+		rp.startQueues(sh)
+	}
+
+	stop := func() {
+		once.Do(func() {
+			close(doneChan)
+			wg.Wait()
+			for _, rp := range paths {
+				rp.stopQueues()
+				rp.Src.Log(fmt.Sprintf("- %s relayer shutting down", rp))
+			}
+		})
+	}
+
+	// Next start the goroutines that listen to each chain for block and tx events
+	for _, c := range chains {
+		cl := listeners[c.ChainID]
+		if err = cl.subscribe(); err != nil {
+			stop()
+			return nil, err
+		}
+		wg.Add(2)
+		go cl.listen(doneChan, &wg, sh)
+		go c.watchRPCEndpoints(doneChan, &wg)
+	}
+
+	// Keep the clients of each path from expiring while it has no packets to relay
+	// and watch them for misbehaviour
+	for _, rp := range paths {
+		wg.Add(1)
+		go rp.keepClients(doneChan, &wg, sh)
+	}
+
+	// Relay any packets that remain to be relayed on each path
+	for _, rp := range paths {
+		if err = rp.RelayUnrelayed(sh); err != nil {
+			stop()
+			return nil, err
+		}
+	}
+
+	return stop, nil
+}
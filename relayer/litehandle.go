@@ -0,0 +1,216 @@
+package relayer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	retry "github.com/avast/retry-go"
+	lite "github.com/tendermint/tendermint/lite2"
+	litestore "github.com/tendermint/tendermint/lite2/store"
+	dbs "github.com/tendermint/tendermint/lite2/store/db"
+	tmtypes "github.com/tendermint/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// liteHandle keeps a chain's lite client database and client open for the
+// life of the process, shared by copies of the chain. Access to the client
+// is serialized, so the daemon and CLI commands go through the same path.
+// Headers the client already trusts are read from the store, which is safe
+// for concurrent use, without waiting for the client.
+type liteHandle struct {
+	sync.Mutex
+	db     *dbm.GoLevelDB
+	store  litestore.Store
+	client *lite.Client
+
+	// held for writing while the store is opened or closed, and for reading
+	// while it is read without holding the handle
+	storeMtx sync.RWMutex
+}
+
+const (
+	// liteDBOpenAttempts is the number of times the lite client database is
+	// opened before giving up, as another relayer process may hold its lock
+	liteDBOpenAttempts = 5
+	// liteDBOpenDelay is the initial delay between attempts, doubled after each
+	liteDBOpenDelay = 200 * time.Millisecond
+)
+
+// LiteStoreInfo describes the trusted headers held in a chain's lite client database
+type LiteStoreInfo struct {
+	Headers     uint16 `json:"headers"`
+	FirstHeight int64  `json:"first-height"`
+	LastHeight  int64  `json:"last-height"`
+	// Size is the size of the database on disk in bytes
+	Size int64 `json:"size"`
+}
+
+// openDB opens the chain's lite client database and store if they aren't open.
+// h must be locked.
+func (h *liteHandle) openDB(c *Chain) error {
+	if h.db != nil {
+		return nil
+	}
+	var db *dbm.GoLevelDB
+	if err := retry.Do(func() (err error) {
+		db, err = dbm.NewGoLevelDB(c.ChainID, liteDir(c.HomePath))
+		return err
+	}, retry.Attempts(liteDBOpenAttempts), retry.Delay(liteDBOpenDelay), retry.LastErrorOnly(true)); err != nil {
+		return fmt.Errorf("can't open lite client database for chain %s, it may be in use by another relayer process: %w",
+			c.ChainID, err)
+	}
+
+	h.storeMtx.Lock()
+	defer h.storeMtx.Unlock()
+	h.db, h.store = db, dbs.New(db, "")
+	return nil
+}
+
+// trustedHeader returns the signed header at height from the store if the lite
+// client already trusts it, or nil if it doesn't or the store isn't open
+func (h *liteHandle) trustedHeader(height int64) *tmtypes.SignedHeader {
+	h.storeMtx.RLock()
+	defer h.storeMtx.RUnlock()
+
+	if h.store == nil {
+		return nil
+	}
+	sh, err := h.store.SignedHeader(height)
+	if err != nil {
+		return nil
+	}
+	return sh
+}
+
+// close closes the client and database. h must be locked.
+func (h *liteHandle) close() error {
+	h.storeMtx.Lock()
+	defer h.storeMtx.Unlock()
+
+	h.client, h.store = nil, nil
+	if h.db == nil {
+		return nil
+	}
+	err := h.db.Close()
+	h.db = nil
+	return err
+}
+
+// withLiteClient calls f with the chain's lite client, loading it from the
+// trusted store on first use
+func (c *Chain) withLiteClient(f func(*lite.Client) error) error {
+	h := c.lite
+	h.Lock()
+	defer h.Unlock()
+
+	if h.client == nil {
+		if err := h.openDB(c); err != nil {
+			return err
+		}
+		client, err := c.LiteClientWithoutTrust(h.store)
+		if err != nil {
+			return err
+		}
+		h.client = client
+	}
+
+	err := f(h.client)
+	// the client drops witnesses that misbehave, so reload it with the configured
+	// witnesses once none are left
+	if err != nil && len(h.client.Witnesses()) == 0 {
+		h.client = nil
+	}
+	return err
+}
+
+// initLiteClient replaces the chain's lite client with one created by newClient
+// from the chain's lite client database
+func (c *Chain) initLiteClient(newClient func(store litestore.Store) (*lite.Client, error)) error {
+	h := c.lite
+	h.Lock()
+	defer h.Unlock()
+
+	if err := h.openDB(c); err != nil {
+		return err
+	}
+	h.client = nil
+	client, err := newClient(h.store)
+	if err != nil {
+		return err
+	}
+	h.client = client
+	return nil
+}
+
+// LiteStoreInfo returns the number of trusted headers in the chain's lite client
+// database, the heights of the first and last of them and the database's size
+func (c *Chain) LiteStoreInfo() (info LiteStoreInfo, err error) {
+	h := c.lite
+	h.Lock()
+	defer h.Unlock()
+
+	if err = h.openDB(c); err != nil {
+		return info, err
+	}
+	if info.FirstHeight, err = h.store.FirstSignedHeaderHeight(); err != nil {
+		return info, err
+	}
+	if info.LastHeight, err = h.store.LastSignedHeaderHeight(); err != nil {
+		return info, err
+	}
+	info.Headers = h.store.Size()
+
+	err = filepath.Walk(liteDBDir(c.HomePath, c.ChainID), func(_ string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() {
+			info.Size += fi.Size()
+		}
+		return err
+	})
+	return info, err
+}
+
+// PruneLite deletes the oldest trusted headers from the chain's lite client
+// database, keeping the lite-cache-size latest ones. It returns the number of
+// headers deleted.
+func (c *Chain) PruneLite() (int, error) {
+	if c.liteCacheSize == 0 {
+		return 0, fmt.Errorf("lite-cache-size is 0, so chain %s keeps every header", c.ChainID)
+	}
+
+	h := c.lite
+	h.Lock()
+	defer h.Unlock()
+
+	if err := h.openDB(c); err != nil {
+		return 0, err
+	}
+	before := h.store.Size()
+	if err := h.store.Prune(c.liteCacheSize); err != nil {
+		return 0, err
+	}
+	return int(before) - int(h.store.Size()), nil
+}
+
+// CloseLite closes the chain's lite client and its database
+func (c *Chain) CloseLite() error {
+	if c.lite == nil {
+		return nil
+	}
+	c.lite.Lock()
+	defer c.lite.Unlock()
+	return c.lite.close()
+}
+
+// CloseLite closes the lite clients of the chains
+func (c Chains) CloseLite() error {
+	var out error
+	for _, chain := range c {
+		if err := chain.CloseLite(); err != nil {
+			out = err
+		}
+	}
+	return out
+}
@@ -0,0 +1,27 @@
+package relayer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	dbs "github.com/tendermint/tendermint/lite2/store/db"
+	tmtypes "github.com/tendermint/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+)
+
+func TestLiteHandleTrustedHeader(t *testing.T) {
+	h := &liteHandle{}
+	require.Nil(t, h.trustedHeader(5), "store isn't open")
+
+	h.store = dbs.New(dbm.NewMemDB(), "")
+	sh := &tmtypes.SignedHeader{Header: &tmtypes.Header{ChainID: "ibc0", Height: 5}}
+	require.NoError(t, h.store.SaveSignedHeaderAndValidatorSet(sh, tmtypes.NewValidatorSet(nil)))
+
+	got := h.trustedHeader(5)
+	require.NotNil(t, got)
+	require.Equal(t, int64(5), got.Height)
+	require.Nil(t, h.trustedHeader(6), "header isn't trusted")
+
+	require.NoError(t, h.close())
+	require.Nil(t, h.trustedHeader(5), "store is closed")
+}
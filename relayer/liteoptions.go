@@ -0,0 +1,141 @@
+package relayer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tendermint/tendermint/libs/log"
+	tmmath "github.com/tendermint/tendermint/libs/math"
+	lite "github.com/tendermint/tendermint/lite2"
+)
+
+const (
+	liteSkippingVerification   = "skipping"
+	liteSequentialVerification = "sequential"
+)
+
+// LiteOptions configures a chain's lite client. Its logs are discarded unless
+// LogLevel is set, and go to LogFile, which is stdout, stderr or a file path
+// relative to the relayer's home (default stderr). Verification is either "skipping" (the default), where
+// headers are trusted once TrustLevel (default 1/3) of a trusted validator set
+// has signed them, or "sequential", where every header is verified in turn.
+type LiteOptions struct {
+	LogLevel     string `yaml:"log-level,omitempty" json:"log-level,omitempty"`
+	LogFile      string `yaml:"log-file,omitempty" json:"log-file,omitempty"`
+	Verification string `yaml:"verification,omitempty" json:"verification,omitempty"`
+	TrustLevel   string `yaml:"trust-level,omitempty" json:"trust-level,omitempty"`
+
+	logger     log.Logger
+	trustLevel tmmath.Fraction
+}
+
+// liteLogFiles holds the lite log files opened by the process by path, so each
+// is opened once however often the chains are initialized
+var liteLogFiles = struct {
+	sync.Mutex
+	writers map[string]io.Writer
+}{writers: make(map[string]io.Writer)}
+
+// openLiteLogFile returns a writer to the log file at path, resolved against
+// homePath if it is relative, opening the file on first use. The file is written
+// to for the life of the process.
+func openLiteLogFile(homePath, path string) (io.Writer, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(homePath, path)
+	}
+
+	liteLogFiles.Lock()
+	defer liteLogFiles.Unlock()
+	if w, ok := liteLogFiles.writers[path]; ok {
+		return w, nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	w := log.NewSyncWriter(f)
+	liteLogFiles.writers[path] = w
+	return w, nil
+}
+
+// init validates the options and opens the log destination
+func (lo *LiteOptions) init(chainID, homePath string) (err error) {
+	lo.trustLevel = lite.DefaultTrustLevel
+	if lo.TrustLevel != "" {
+		if lo.trustLevel, err = parseFraction(lo.TrustLevel); err != nil {
+			return fmt.Errorf("invalid lite trust-level (%s): %w", lo.TrustLevel, err)
+		}
+		if err = lite.ValidateTrustLevel(lo.trustLevel); err != nil {
+			return fmt.Errorf("invalid lite trust-level (%s): %w", lo.TrustLevel, err)
+		}
+	}
+
+	switch lo.Verification {
+	case "", liteSkippingVerification:
+	case liteSequentialVerification:
+		if lo.TrustLevel != "" {
+			return fmt.Errorf("lite trust-level only applies to %s verification", liteSkippingVerification)
+		}
+	default:
+		return fmt.Errorf("invalid lite verification (%s), must be %s or %s",
+			lo.Verification, liteSkippingVerification, liteSequentialVerification)
+	}
+
+	lo.logger = log.NewNopLogger()
+	if lo.LogLevel == "" {
+		return nil
+	}
+	allow, err := log.AllowLevel(lo.LogLevel)
+	if err != nil {
+		return fmt.Errorf("invalid lite log-level: %w", err)
+	}
+
+	var w io.Writer
+	switch lo.LogFile {
+	case "", "stderr":
+		w = log.NewSyncWriter(os.Stderr)
+	case "stdout":
+		w = log.NewSyncWriter(os.Stdout)
+	default:
+		if w, err = openLiteLogFile(homePath, lo.LogFile); err != nil {
+			return fmt.Errorf("failed to open lite log-file: %w", err)
+		}
+	}
+	lo.logger = log.NewFilter(log.NewTMLogger(w), allow).With("chain-id", chainID)
+	return nil
+}
+
+// liteClientOptions returns the options the chain's lite clients are created with
+func (c *Chain) liteClientOptions() []lite.Option {
+	lo := c.LiteOptions
+	if lo == nil || lo.logger == nil {
+		lo = &LiteOptions{logger: log.NewNopLogger(), trustLevel: lite.DefaultTrustLevel}
+	}
+
+	verification := lite.SkippingVerification(lo.trustLevel)
+	if lo.Verification == liteSequentialVerification {
+		verification = lite.SequentialVerification()
+	}
+
+	return []lite.Option{lite.Logger(lo.logger), verification, lite.PruningSize(c.liteCacheSize)}
+}
+
+// parseFraction parses a fraction such as 1/3
+func parseFraction(s string) (f tmmath.Fraction, err error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return f, fmt.Errorf("expected a fraction such as 1/3")
+	}
+	if f.Numerator, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return f, err
+	}
+	if f.Denominator, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return f, err
+	}
+	return f, nil
+}
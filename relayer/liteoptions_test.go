@@ -0,0 +1,25 @@
+package relayer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenLiteLogFile(t *testing.T) {
+	home, err := ioutil.TempDir("", "relayer-home")
+	require.NoError(t, err)
+	defer os.RemoveAll(home)
+
+	w, err := openLiteLogFile(home, "lite.log")
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(home, "lite.log"))
+	require.NoError(t, err, "relative paths are resolved under the home dir")
+
+	again, err := openLiteLogFile(home, filepath.Join(home, "lite.log"))
+	require.NoError(t, err)
+	require.True(t, w == again, "the file is opened once")
+}
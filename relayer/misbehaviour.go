@@ -0,0 +1,211 @@
+package relayer
+
+import (
+	"bytes"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	tmclient "github.com/cosmos/cosmos-sdk/x/ibc/07-tendermint/types"
+)
+
+// maxClientUpdatesPerPage is the number of client update txs fetched per page
+// when searching for the headers a client accepted between checks
+const maxClientUpdatesPerPage = 100
+
+// Misbehaviour describes a client that accepted a header conflicting with the
+// header of its chain at the same height. It is passed to a path's
+// OnMisbehaviour handler.
+type Misbehaviour struct {
+	// ChainID and ClientID identify the client that accepted the header
+	ChainID  string
+	ClientID string
+	// CounterpartyChainID is the chain the client tracks
+	CounterpartyChainID string
+	Height              int64
+	// AcceptedHash is the hash of the header the client accepted, and
+	// ConflictingHash that of the header the counterparty's lite client verified
+	AcceptedHash    []byte
+	ConflictingHash []byte
+	// Submitted is set if evidence freezing the client was submitted
+	Submitted bool
+}
+
+// clientCheck records how far the client of a chain on its counterparty has been
+// checked for misbehaviour. It is only used by the path's client keeper goroutine.
+type clientCheck struct {
+	// the height and hash of the client's latest header when it was last checked
+	height int64
+	hash   []byte
+	// the height of the client's chain up to which its updates were searched
+	searched int64
+}
+
+// checkMisbehaviour checks the headers the client of dst on src accepted since
+// the last check against the headers of dst at the same heights. A conflicting
+// header means the client was updated with a header dst's validators also signed
+// on a fork, so the two headers are submitted to src as misbehaviour evidence to
+// freeze the client. It returns true if misbehaviour was found.
+func (rp *RelayPath) checkMisbehaviour(src, dst *Chain, sh *SyncHeaders, cs tmclient.ClientState, check *clientCheck) bool {
+	// the first check starts the search for updates from the latest height, as the
+	// client's latest header is checked anyway
+	latestHeight := int64(sh.GetHeight(src.ChainID))
+	if check.searched == 0 {
+		check.searched = latestHeight
+	}
+	headers, err := src.clientUpdateHeaders(check.searched, latestHeight)
+	if err != nil {
+		src.Error(fmt.Errorf("misbehaviour check of client(%s) failed to search its updates: %w", src.PathEnd.ClientID, err))
+		return false
+	}
+	latest := cs.LastHeader
+	if latest.Height != 0 && (latest.Height != check.height || !bytes.Equal(latest.Hash(), check.hash)) {
+		headers = append(headers, latest)
+	}
+
+	for _, accepted := range headers {
+		conflicting, err := dst.conflictingHeader(accepted)
+		if err != nil {
+			src.Error(fmt.Errorf("misbehaviour check of client(%s) failed at height %d of %s: %w",
+				src.PathEnd.ClientID, accepted.Height, dst.ChainID, err))
+			return false
+		}
+		if conflicting != nil {
+			// the headers are checked again until the evidence is submitted
+			if rp.reportMisbehaviour(src, dst, accepted, *conflicting) {
+				check.searched = latestHeight
+			}
+			return true
+		}
+	}
+
+	check.height, check.hash, check.searched = latest.Height, latest.Hash(), latestHeight
+	return false
+}
+
+// clientUpdateHeaders returns the headers the client on c was updated with by txs
+// committed after height from and up to height to
+func (c *Chain) clientUpdateHeaders(from, to int64) ([]tmclient.Header, error) {
+	if to <= from {
+		return nil, nil
+	}
+
+	events := []string{
+		fmt.Sprintf("update_client.client_id='%s'", c.PathEnd.ClientID),
+		fmt.Sprintf("tx.height>%d", from),
+		fmt.Sprintf("tx.height<=%d", to),
+	}
+
+	var headers []tmclient.Header
+	for page := 1; ; page++ {
+		res, err := c.QueryTxs(uint64(to), page, maxClientUpdatesPerPage, events)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range res.Txs {
+			if tx.Code != 0 || tx.Tx == nil {
+				continue
+			}
+			for _, msg := range tx.Tx.GetMsgs() {
+				if update, ok := msg.(tmclient.MsgUpdateClient); ok && update.ClientID == c.PathEnd.ClientID {
+					headers = append(headers, update.Header)
+				}
+			}
+		}
+		if page*maxClientUpdatesPerPage >= res.TotalCount {
+			return headers, nil
+		}
+	}
+}
+
+// conflictingHeader returns the header of the chain at the height of accepted if
+// it conflicts with accepted, or nil if it doesn't. The header is compared with
+// the one the lite client already trusts at that height, or else with the node's
+// commit. Only a header that differs from the node's commit is verified by the lite
+// client, so a lying node isn't taken for misbehaviour.
+func (c *Chain) conflictingHeader(accepted tmclient.Header) (*tmclient.Header, error) {
+	if trusted := c.lite.trustedHeader(accepted.Height); trusted != nil {
+		if bytes.Equal(trusted.Hash(), accepted.Hash()) {
+			return nil, nil
+		}
+		return c.GetLiteSignedHeaderAtHeight(accepted.Height)
+	}
+
+	node, err := c.QueryHeaderAtHeight(accepted.Height)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(node.Hash(), accepted.Hash()) {
+		return nil, nil
+	}
+
+	verified, err := c.UpdateLiteWithHeaderHeight(accepted.Height)
+	switch {
+	case err != nil:
+		return nil, liteError(err)
+	case bytes.Equal(verified.Hash(), accepted.Hash()):
+		return nil, fmt.Errorf("node at %s serves header %X, but the lite client verified the accepted header %X",
+			c.ActiveRPCAddr(), node.Hash(), accepted.Hash())
+	}
+	return verified, nil
+}
+
+// misbehaviourEvidence returns the evidence that the client on chainID's counterparty
+// accepted a header conflicting with the header of chainID at the same height
+func misbehaviourEvidence(clientID, chainID string, accepted, conflicting tmclient.Header) (tmclient.Evidence, error) {
+	ev := tmclient.Evidence{
+		ClientID: clientID,
+		Header1:  accepted,
+		Header2:  conflicting,
+		ChainID:  chainID,
+	}
+	if err := ev.ValidateBasic(); err != nil {
+		return tmclient.Evidence{}, err
+	}
+	return ev, nil
+}
+
+// reportMisbehaviour logs the misbehaviour of the client of dst on src, submits
+// evidence of it to src and passes it to the path's OnMisbehaviour handler. It
+// returns true if the evidence was submitted.
+func (rp *RelayPath) reportMisbehaviour(src, dst *Chain, accepted, conflicting tmclient.Header) bool {
+	m := Misbehaviour{
+		ChainID:             src.ChainID,
+		ClientID:            src.PathEnd.ClientID,
+		CounterpartyChainID: dst.ChainID,
+		Height:              accepted.Height,
+		AcceptedHash:        accepted.Hash(),
+		ConflictingHash:     conflicting.Hash(),
+	}
+	src.Error(fmt.Errorf("MISBEHAVIOUR: client(%s) on %s accepted header %X of %s at height %d, but the lite client verified header %X",
+		m.ClientID, m.ChainID, m.AcceptedHash, m.CounterpartyChainID, m.Height, m.ConflictingHash))
+
+	m.Submitted = rp.submitMisbehaviour(src, dst, accepted, conflicting)
+	if rp.OnMisbehaviour != nil {
+		if err := rp.OnMisbehaviour(m); err != nil {
+			src.Error(fmt.Errorf("misbehaviour handler for client(%s) on %s failed: %w", m.ClientID, m.ChainID, err))
+		}
+	}
+	return m.Submitted
+}
+
+// submitMisbehaviour submits evidence of the conflicting headers to src, which
+// freezes the client. It returns true if the evidence was submitted.
+func (rp *RelayPath) submitMisbehaviour(src, dst *Chain, accepted, conflicting tmclient.Header) bool {
+	ev, err := misbehaviourEvidence(src.PathEnd.ClientID, dst.ChainID, accepted, conflicting)
+	if err != nil {
+		src.Error(fmt.Errorf("can't submit misbehaviour evidence for client(%s) on %s: %w",
+			src.PathEnd.ClientID, src.ChainID, err))
+		return false
+	}
+
+	msg := tmclient.NewMsgSubmitClientMisbehaviour(ev, src.MustGetAddress())
+	if br := send(src, []sdk.Msg{msg}); !br.Success() {
+		src.Error(fmt.Errorf("failed to submit misbehaviour evidence for client(%s) on %s, freeze it manually",
+			src.PathEnd.ClientID, src.ChainID))
+		return false
+	}
+
+	src.Log(fmt.Sprintf("★ Misbehaviour submitted: [%s]client(%s) of %s frozen at height %d",
+		src.ChainID, src.PathEnd.ClientID, dst.ChainID, ev.GetHeight()))
+	return true
+}
@@ -0,0 +1,54 @@
+package relayer
+
+import (
+	"testing"
+	"time"
+
+	tmclient "github.com/cosmos/cosmos-sdk/x/ibc/07-tendermint/types"
+	"github.com/stretchr/testify/require"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func TestMisbehaviourEvidence(t *testing.T) {
+	privVal := tmtypes.NewMockPV()
+	pubKey, err := privVal.GetPubKey()
+	require.NoError(t, err)
+	valSet := tmtypes.NewValidatorSet([]*tmtypes.Validator{tmtypes.NewValidator(pubKey, 10)})
+	signers := []tmtypes.PrivValidator{privVal}
+	now := time.Now().UTC()
+
+	accepted := tmclient.CreateTestHeader("ibc1", 10, now, valSet, signers)
+	fork := tmclient.CreateTestHeader("ibc1", 10, now.Add(time.Minute), valSet, signers)
+	later := tmclient.CreateTestHeader("ibc1", 11, now.Add(time.Minute), valSet, signers)
+	otherChain := tmclient.CreateTestHeader("ibc2", 10, now.Add(time.Minute), valSet, signers)
+
+	tests := []struct {
+		name        string
+		conflicting tmclient.Header
+		valid       bool
+	}{
+		{"conflicting header", fork, true},
+		{"same header", accepted, false},
+		{"different height", later, false},
+		{"different chain", otherChain, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ev, err := misbehaviourEvidence("ibconeclient", "ibc1", accepted, tc.conflicting)
+			if !tc.valid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "ibconeclient", ev.ClientID)
+			require.Equal(t, "ibc1", ev.ChainID)
+			require.Equal(t, accepted.Hash(), ev.Header1.Hash())
+			require.Equal(t, tc.conflicting.Hash(), ev.Header2.Hash())
+			require.Equal(t, int64(10), ev.GetHeight())
+		})
+	}
+
+	_, err = misbehaviourEvidence("bad", "ibc1", accepted, fork)
+	require.Error(t, err, "invalid client id")
+}
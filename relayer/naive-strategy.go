@@ -9,12 +9,67 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
-var _ Strategy = &NaiveStrategy{}
+var (
+	_ Strategy        = &NaiveStrategy{}
+	_ BatchedStrategy = &NaiveStrategy{}
+)
+
+const (
+	// MB is the number of bytes in a megabyte
+	MB = 1048576
+
+	// DefaultMaxTxSize is the default max size (in MB) of the msgs in a relay transaction
+	DefaultMaxTxSize = 2
+	// DefaultMaxMsgLength is the default max number of msgs in a relay transaction
+	DefaultMaxMsgLength = 5
+)
+
+func init() {
+	RegisterStrategy((&NaiveStrategy{}).GetType(), newNaiveStrategy, decodeNaiveStrategyOptions)
+}
+
+// NaiveStrategyOptions are the options for the NaiveStrategy set in the path config.
+// A limit of 0 means that relay transactions aren't limited by that measure.
+type NaiveStrategyOptions struct {
+	MaxTxSize    uint64 `json:"max-tx-size" yaml:"max-tx-size"` // in MB
+	MaxMsgLength uint64 `json:"max-msgs" yaml:"max-msgs"`
+}
+
+// DefaultNaiveStrategyOptions returns the options used for unset config values
+func DefaultNaiveStrategyOptions() *NaiveStrategyOptions {
+	return &NaiveStrategyOptions{
+		MaxTxSize:    DefaultMaxTxSize,
+		MaxMsgLength: DefaultMaxMsgLength,
+	}
+}
+
+func decodeNaiveStrategyOptions(unmarshal func(interface{}) error) (interface{}, error) {
+	opts := DefaultNaiveStrategyOptions()
+	if err := unmarshal(opts); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+func newNaiveStrategy(cfg *StrategyCfg) (Strategy, error) {
+	opts := DefaultNaiveStrategyOptions()
+	if cfg.Options != nil {
+		var ok bool
+		if opts, ok = cfg.Options.(*NaiveStrategyOptions); !ok {
+			return nil, fmt.Errorf("naive strategy options have invalid type %T", cfg.Options)
+		}
+	}
+	return &NaiveStrategy{
+		MaxTxSize:    opts.MaxTxSize * MB,
+		MaxMsgLength: opts.MaxMsgLength,
+	}, nil
+}
 
 // NewNaiveStrategy returns the proper config for the NaiveStrategy
 func NewNaiveStrategy() *StrategyCfg {
 	return &StrategyCfg{
-		Type: (&NaiveStrategy{}).GetType(),
+		Type:    (&NaiveStrategy{}).GetType(),
+		Options: DefaultNaiveStrategyOptions(),
 	}
 }
 
@@ -30,6 +85,17 @@ func (nrs *NaiveStrategy) GetType() string {
 	return "naive"
 }
 
+// BatchLimits implements BatchedStrategy
+func (nrs *NaiveStrategy) BatchLimits() (maxTxSize, maxMsgLength uint64) {
+	return nrs.MaxTxSize, nrs.MaxMsgLength
+}
+
+// SetBatchLimits implements BatchedStrategy
+func (nrs *NaiveStrategy) SetBatchLimits(maxTxSize, maxMsgLength uint64) {
+	nrs.MaxTxSize = maxTxSize
+	nrs.MaxMsgLength = maxMsgLength
+}
+
 // UnrelayedSequencesOrdered returns the unrelayed sequence numbers between two chains
 func (nrs *NaiveStrategy) UnrelayedSequencesOrdered(src, dst *Chain, sh *SyncHeaders) (*RelaySequences, error) {
 	return UnrelayedSequences(src, dst, sh)
@@ -37,7 +103,7 @@ func (nrs *NaiveStrategy) UnrelayedSequencesOrdered(src, dst *Chain, sh *SyncHea
 
 // UnrelayedSequencesUnordered returns the unrelayed sequence numbers between two chains
 func (nrs *NaiveStrategy) UnrelayedSequencesUnordered(src, dst *Chain, sh *SyncHeaders) (*RelaySequences, error) {
-	return UnrelayedSequences(src, dst, sh)
+	return UnrelayedSequencesUnordered(src, dst, sh)
 }
 
 // HandleEvents defines how the relayer will handle block and transaction events as they are emmited
@@ -53,8 +119,6 @@ func relayPacketsFromEventListener(src, dst *PathEnd, events map[string][]string
 	if pdval, ok := events["send_packet.packet_data"]; ok {
 		for i, pd := range pdval {
 			// Ensure that we only relay over the channel and port specified
-			// OPTIONAL FEATURE: add additional filtering options
-			// Example Events - "transfer.amount(sdk.Coin)", "message.sender(sdk.AccAddress)"
 			srcChan, srcPort := events["send_packet.packet_src_channel"], events["send_packet.packet_src_port"]
 			dstChan, dstPort := events["send_packet.packet_dst_channel"], events["send_packet.packet_dst_port"]
 
@@ -99,7 +163,6 @@ func relayPacketsFromEventListener(src, dst *PathEnd, events map[string][]string
 	if pdval, ok := events["recv_packet.packet_data"]; ok {
 		for i, pd := range pdval {
 			// Ensure that we only relay over the channel and port specified
-			// OPTIONAL FEATURE: add additional filtering options
 			srcChan, srcPort := events["recv_packet.packet_src_channel"], events["recv_packet.packet_src_port"]
 			dstChan, dstPort := events["recv_packet.packet_dst_channel"], events["recv_packet.packet_dst_port"]
 
@@ -183,22 +246,40 @@ func (nrs *NaiveStrategy) sendTxFromEventPackets(src, dst *Chain, rlyPackets []r
 	}
 }
 
-// RelayPacketsUnorderedChan creates transactions to relay un-relayed messages
+// RelayPacketsUnorderedChan creates transactions to relay un-relayed messages. Packets on
+// unordered channels don't depend on each other, so a packet that can't be relayed is
+// logged and skipped instead of holding up the rest of the packets.
 func (nrs *NaiveStrategy) RelayPacketsUnorderedChan(src, dst *Chain, sp *RelaySequences, sh *SyncHeaders) error {
-	// TODO: Implement unordered channels
-	return nrs.RelayPacketsOrderedChan(src, dst, sp, sh)
+	msgs := nrs.newRelayMsgs()
+
+	// add messages for src -> dst
+	for _, seq := range sp.Src {
+		chain, msg, err := packetMsgFromTxQuery(src, dst, sh, seq)
+		if err != nil {
+			src.Error(err)
+			continue
+		}
+		msgs.add(src, dst, chain, msg)
+	}
+
+	// add messages for dst -> src
+	for _, seq := range sp.Dst {
+		chain, msg, err := packetMsgFromTxQuery(dst, src, sh, seq)
+		if err != nil {
+			dst.Error(err)
+			continue
+		}
+		msgs.add(src, dst, chain, msg)
+	}
+
+	nrs.sendRelayMsgs(src, dst, msgs, sh)
+	return nil
 }
 
 // RelayPacketsOrderedChan creates transactions to clear both queues
 // CONTRACT: the SyncHeaders passed in here must be up to date or being kept updated
 func (nrs *NaiveStrategy) RelayPacketsOrderedChan(src, dst *Chain, sp *RelaySequences, sh *SyncHeaders) error {
-	// set the maximum relay transaction constraints
-	msgs := &RelayMsgs{
-		Src:          []sdk.Msg{},
-		Dst:          []sdk.Msg{},
-		MaxTxSize:    nrs.MaxTxSize,
-		MaxMsgLength: nrs.MaxMsgLength,
-	}
+	msgs := nrs.newRelayMsgs()
 
 	// add messages for src -> dst
 	for _, seq := range sp.Src {
@@ -206,11 +287,7 @@ func (nrs *NaiveStrategy) RelayPacketsOrderedChan(src, dst *Chain, sp *RelaySequ
 		if err != nil {
 			return err
 		}
-		if chain == dst {
-			msgs.Dst = append(msgs.Dst, msg)
-		} else {
-			msgs.Src = append(msgs.Src, msg)
-		}
+		msgs.add(src, dst, chain, msg)
 	}
 
 	// add messages for dst -> src
@@ -219,16 +296,28 @@ func (nrs *NaiveStrategy) RelayPacketsOrderedChan(src, dst *Chain, sp *RelaySequ
 		if err != nil {
 			return err
 		}
-		if chain == src {
-			msgs.Src = append(msgs.Src, msg)
-		} else {
-			msgs.Dst = append(msgs.Dst, msg)
-		}
+		msgs.add(src, dst, chain, msg)
 	}
 
+	nrs.sendRelayMsgs(src, dst, msgs, sh)
+	return nil
+}
+
+// newRelayMsgs returns empty RelayMsgs with the maximum relay transaction constraints set
+func (nrs *NaiveStrategy) newRelayMsgs() *RelayMsgs {
+	return &RelayMsgs{
+		Src:          []sdk.Msg{},
+		Dst:          []sdk.Msg{},
+		MaxTxSize:    nrs.MaxTxSize,
+		MaxMsgLength: nrs.MaxMsgLength,
+	}
+}
+
+// sendRelayMsgs prepends the client updates to the msgs and sends them to both chains
+func (nrs *NaiveStrategy) sendRelayMsgs(src, dst *Chain, msgs *RelayMsgs, sh *SyncHeaders) {
 	if !msgs.Ready() {
 		src.Log(fmt.Sprintf("- No packets to relay between [%s]port{%s} and [%s]port{%s}", src.ChainID, src.PathEnd.PortID, dst.ChainID, dst.PathEnd.PortID))
-		return nil
+		return
 	}
 
 	// Prepend non-empty msg lists with UpdateClient
@@ -239,38 +328,42 @@ func (nrs *NaiveStrategy) RelayPacketsOrderedChan(src, dst *Chain, sp *RelaySequ
 		msgs.Src = append([]sdk.Msg{src.PathEnd.UpdateClient(sh.GetHeader(dst.ChainID), src.MustGetAddress())}, msgs.Src...)
 	}
 
-	// TODO: increase the amount of gas as the number of messages increases
-	// notify the user of that
-	if msgs.Send(src, dst); msgs.success {
-		if len(msgs.Dst) > 1 {
-			dst.logPacketsRelayed(src, len(msgs.Dst)-1)
-		}
-		if len(msgs.Src) > 1 {
-			src.logPacketsRelayed(dst, len(msgs.Src)-1)
-		}
+	res := msgs.Send(src, dst)
+	if len(msgs.Dst) > 1 && res.DstSuccess() {
+		dst.logPacketsRelayed(src, len(msgs.Dst)-1)
+	}
+	if len(msgs.Src) > 1 && res.SrcSuccess() {
+		src.logPacketsRelayed(dst, len(msgs.Src)-1)
 	}
-
-	return nil
 }
 
-// packetMsgFromTxQuery returns a sdk.Msg to relay a packet with a given seq on src
-func packetMsgFromTxQuery(src, dst *Chain, sh *SyncHeaders, seq uint64) (*Chain, sdk.Msg, error) {
+// packetTxFromQuery returns the tx that sent the packet with a given seq on src
+func packetTxFromQuery(src *Chain, sh *SyncHeaders, seq uint64) (sdk.TxResponse, error) {
 	eveSend, err := ParseEvents(fmt.Sprintf(defaultPacketSendQuery, src.PathEnd.ChannelID, seq))
 	if err != nil {
-		return nil, nil, err
+		return sdk.TxResponse{}, err
 	}
 
 	tx, err := src.QueryTxs(sh.GetHeight(src.ChainID), 1, 1000, eveSend)
 	switch {
 	case err != nil:
-		return nil, nil, err
+		return sdk.TxResponse{}, err
 	case tx.Count == 0:
-		return nil, nil, fmt.Errorf("no transactions returned with query")
+		return sdk.TxResponse{}, fmt.Errorf("no transactions returned with query")
 	case tx.Count > 1:
-		return nil, nil, fmt.Errorf("more than one transaction returned with query")
+		return sdk.TxResponse{}, fmt.Errorf("more than one transaction returned with query")
 	}
+	return tx.Txs[0], nil
+}
 
-	rcvPackets, timeoutPackets, err := relayPacketFromQueryResponse(src.PathEnd, dst.PathEnd, tx.Txs[0], sh)
+// packetMsgFromTxQuery returns a sdk.Msg to relay a packet with a given seq on src
+func packetMsgFromTxQuery(src, dst *Chain, sh *SyncHeaders, seq uint64) (*Chain, sdk.Msg, error) {
+	tx, err := packetTxFromQuery(src, sh, seq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rcvPackets, timeoutPackets, err := relayPacketFromQueryResponse(src.PathEnd, dst.PathEnd, tx, sh, nil)
 	switch {
 	case err != nil:
 		return nil, nil, err
@@ -316,8 +409,10 @@ func packetMsgFromTxQuery(src, dst *Chain, sh *SyncHeaders, seq uint64) (*Chain,
 }
 
 // relayPacketFromQueryResponse looks through the events in a sdk.Response
-// and returns relayPackets with the appropriate data
-func relayPacketFromQueryResponse(src, dst *PathEnd, res sdk.TxResponse, sh *SyncHeaders) (rcvPackets []relayPacket, timeoutPackets []relayPacket, err error) {
+// and returns relayPackets with the appropriate data, or errPacketFiltered if the
+// only packet found isn't allowed by the filter
+func relayPacketFromQueryResponse(src, dst *PathEnd, res sdk.TxResponse, sh *SyncHeaders, filter *PacketFilter) (rcvPackets []relayPacket, timeoutPackets []relayPacket, err error) {
+	var filtered bool
 	for _, l := range res.Logs {
 		for _, e := range l.Events {
 			if e.Type == "send_packet" {
@@ -371,6 +466,8 @@ func relayPacketFromQueryResponse(src, dst *PathEnd, res sdk.TxResponse, sh *Syn
 					timeoutPackets = append(timeoutPackets, rp.timeoutPacket())
 				case rp.timeoutStamp != 0 && time.Now().UnixNano() >= int64(rp.timeoutStamp):
 					timeoutPackets = append(timeoutPackets, rp.timeoutPacket())
+				case !rp.pass && !filter.Allows(src.PortID, src.ChannelID, rp.packetData):
+					filtered = true
 				case !rp.pass:
 					rcvPackets = append(rcvPackets, rp)
 				}
@@ -378,8 +475,11 @@ func relayPacketFromQueryResponse(src, dst *PathEnd, res sdk.TxResponse, sh *Syn
 		}
 	}
 
-	if len(rcvPackets)+len(timeoutPackets) > 0 {
+	switch {
+	case len(rcvPackets)+len(timeoutPackets) > 0:
 		return
+	case filtered:
+		return nil, nil, errPacketFiltered
 	}
 
 	return nil, nil, fmt.Errorf("no packet data found")
@@ -116,9 +116,16 @@ func (p *Path) Validate() (err error) {
 	if _, err = p.GetStrategy(); err != nil {
 		return err
 	}
+	if err = p.Strategy.Filter.Validate(); err != nil {
+		return err
+	}
 	if p.Src.Order != p.Dst.Order {
 		return fmt.Errorf("Both sides must have same order ('ORDERED' or 'UNORDERED'), got src(%s) and dst(%s)", p.Src.Order, p.Dst.Order)
 	}
+	// packets on ordered channels must be received in turn, so skipping one stalls the channel
+	if p.Src.getOrder() == ibctypes.ORDERED && !p.Strategy.Filter.Empty() {
+		return fmt.Errorf("packet filters can't be used on paths over ordered channels")
+	}
 	return nil
 }
 
@@ -157,9 +164,7 @@ func GenPath(srcChainID, dstChainID, srcPortID, dstPortID, order string) *Path {
 			PortID:       dstPortID,
 			Order:        order,
 		},
-		Strategy: &StrategyCfg{
-			Type: "naive",
-		},
+		Strategy: NewNaiveStrategy(),
 	}
 }
 
@@ -224,9 +229,7 @@ func FindPaths(chains Chains) (*Paths, error) {
 									ChannelID:    chn.Counterparty.GetChannelID(),
 									PortID:       chn.Counterparty.GetPortID(),
 								},
-								Strategy: &StrategyCfg{
-									Type: "naive",
-								},
+								Strategy: NewNaiveStrategy(),
 							}
 							if err = out.Add(fmt.Sprintf("%s-%s", src.ChainID, dst.ChainID), p); err != nil {
 								return nil, err
@@ -5,12 +5,15 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/codec"
 	"github.com/cosmos/cosmos-sdk/store/rootmulti"
+	storetypes "github.com/cosmos/cosmos-sdk/store/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	authTypes "github.com/cosmos/cosmos-sdk/x/auth/types"
 	bankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
@@ -34,7 +37,7 @@ var eventFormat = "{eventType}.{eventAttribute}={value}"
 // All the operations here hit the network and data coming back may be untrusted.
 // These functions by convention are named Query*
 
-// TODO: Validate all info coming back from these queries using the verifier
+// Store queries made with Prove set and tx queries are verified against the lite client
 
 // QueryBalance returns the amount of coins in the relayer account
 func (c *Chain) QueryBalance(keyName string) (sdk.Coins, error) {
@@ -47,12 +50,8 @@ func (c *Chain) QueryBalance(keyName string) (sdk.Coins, error) {
 	)
 	if keyName == "" {
 		addr = c.MustGetAddress()
-	} else {
-		info, err := c.Keybase.Key(keyName)
-		if err != nil {
-			return nil, err
-		}
-		addr = info.GetAddress()
+	} else if addr, err = c.KeyAddress(keyName); err != nil {
+		return nil, err
 	}
 
 	if bz, err = c.Cdc.MarshalJSON(bankTypes.NewQueryAllBalancesParams(addr)); err != nil {
@@ -663,15 +662,53 @@ func UnrelayedSequences(src, dst *Chain, sh *SyncHeaders) (*RelaySequences, erro
 	return seqP.ToRelay(), err
 }
 
+// UnrelayedSequencesUnordered returns the unrelayed sequence numbers between two chains
+// connected by an unordered channel. Packets on unordered channels may be received out of
+// order, so the sequences are found by checking each outstanding packet commitment on one
+// side for an acknowledgement on the other rather than by comparing next send and recv seqs.
+func UnrelayedSequencesUnordered(src, dst *Chain, sh *SyncHeaders) (*RelaySequences, error) {
+	var (
+		wg  sync.WaitGroup
+		rs  = &RelaySequences{Src: []uint64{}, Dst: []uint64{}}
+		mtx sync.Mutex
+		es  = errs{}
+	)
+
+	unreceived := func(sender, receiver *Chain, out *[]uint64) {
+		defer wg.Done()
+		seqs, err := sender.QueryPacketCommitmentSeqs(int64(sh.GetHeight(sender.ChainID) - 1))
+		if err == nil {
+			seqs, err = receiver.QueryUnreceivedPackets(int64(sh.GetHeight(receiver.ChainID)-1), seqs)
+		}
+		mtx.Lock()
+		defer mtx.Unlock()
+		if err != nil {
+			es = append(es, err)
+			return
+		}
+		*out = seqs
+	}
+
+	wg.Add(2)
+	go unreceived(src, dst, &rs.Src)
+	go unreceived(dst, src, &rs.Dst)
+	wg.Wait()
+
+	if err := es.err(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
 // QueryNextSeqPairs returns a pair of chain's next sequences for the configured channel
 func QueryNextSeqPairs(src, dst *Chain, sh *SyncHeaders) (*SeqPairs, error) {
 	sps := &SeqPairs{Src: &SeqPair{}, Dst: &SeqPair{}, errs: errs{}}
 	var wg sync.WaitGroup
 	wg.Add(4)
-	go src.queryNextSendWG(sps, int64(sh.GetHeight(src.ChainID)), &wg, true)
-	go src.queryNextRecvWG(sps, int64(sh.GetHeight(src.ChainID)), &wg, true)
-	go dst.queryNextSendWG(sps, int64(sh.GetHeight(dst.ChainID)), &wg, false)
-	go dst.queryNextRecvWG(sps, int64(sh.GetHeight(dst.ChainID)), &wg, false)
+	go src.queryNextSendWG(sps, int64(sh.GetHeight(src.ChainID)-1), &wg, true)
+	go src.queryNextRecvWG(sps, int64(sh.GetHeight(src.ChainID)-1), &wg, true)
+	go dst.queryNextSendWG(sps, int64(sh.GetHeight(dst.ChainID)-1), &wg, false)
+	go dst.queryNextRecvWG(sps, int64(sh.GetHeight(dst.ChainID)-1), &wg, false)
 	wg.Wait()
 	return sps, sps.errs.err()
 }
@@ -814,6 +851,188 @@ func qPacketAckErr(err error) error {
 	return fmt.Errorf("query packet acknowledgement failed: %w", err)
 }
 
+// maxCommitmentChecks is the number of the latest sequences sent over a channel whose
+// commitments are queried with proofs to find packets missing from the commitment listing
+const maxCommitmentChecks = 20
+
+// QueryPacketCommitmentSeqs returns the sequences of the packets sent over the configured
+// channel that still have a commitment stored, i.e. that have not been acknowledged or timed out.
+// NOTE: subspace queries aren't proven, so the node may omit or add sequences. The listing is
+// cross-checked against the proven next sequence to send, and the commitment of each packet is
+// proven when it is queried to be relayed.
+func (c *Chain) QueryPacketCommitmentSeqs(height int64) ([]uint64, error) {
+	if !c.PathSet() {
+		return nil, c.ErrPathNotSet()
+	}
+
+	// commitments are stored under commitments/ports/{port}/channels/{channel}/packets/{seq}
+	key := ibctypes.PacketCommitmentPath(c.PathEnd.PortID, c.PathEnd.ChannelID, 0)
+	prefix := key[:strings.LastIndex(key, "/")+1]
+
+	req := abci.RequestQuery{
+		Path:   "store/ibc/subspace",
+		Data:   []byte(prefix),
+		Height: height,
+	}
+
+	res, err := c.QueryABCI(req)
+	if err != nil {
+		return nil, qPacketCommitmentSeqsErr(err)
+	} else if res.Value == nil {
+		return []uint64{}, nil
+	}
+
+	var kvs []storetypes.KVPair
+	if err = c.Amino.UnmarshalBinaryBare(res.Value, &kvs); err != nil {
+		return nil, qPacketCommitmentSeqsErr(err)
+	}
+
+	seqs := make([]uint64, 0, len(kvs))
+	for _, kv := range kvs {
+		seq, err := strconv.ParseUint(strings.TrimPrefix(string(kv.Key), prefix), 10, 64)
+		if err != nil {
+			return nil, qPacketCommitmentSeqsErr(err)
+		}
+		seqs = append(seqs, seq)
+	}
+
+	if c.SkipProofVerification {
+		sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+		return seqs, nil
+	}
+	return c.checkCommitmentSeqs(height, seqs)
+}
+
+// checkCommitmentSeqs cross-checks the listed commitment sequences against the proven
+// next sequence to send over the channel. Listed sequences that were never sent are
+// dropped, and the commitments of the latest sent sequences that aren't listed are
+// queried with proofs, so that the packets a node left out of the listing are relayed.
+// Both are logged as errors, as they show that the node is lying.
+func (c *Chain) checkCommitmentSeqs(height int64, seqs []uint64) ([]uint64, error) {
+	nextSend, err := c.QueryNextSeqSend(height)
+	if err != nil {
+		return nil, qPacketCommitmentSeqsErr(err)
+	}
+
+	listed := make(map[uint64]bool, len(seqs))
+	checked := make([]uint64, 0, len(seqs))
+	for _, seq := range seqs {
+		if seq == 0 || seq >= nextSend {
+			c.Error(fmt.Errorf("- [%s]@{%d} - node at %s listed a commitment of unsent packet seq(%d), next sequence to send is %d",
+				c.ChainID, height, c.ActiveRPCAddr(), seq, nextSend))
+			continue
+		}
+		listed[seq] = true
+		checked = append(checked, seq)
+	}
+
+	var missing []uint64
+	for seq := nextSend - 1; seq > 0 && nextSend-seq <= maxCommitmentChecks; seq-- {
+		if listed[seq] {
+			continue
+		}
+		res, err := c.QueryPacketCommitment(height, int64(seq))
+		if err != nil {
+			return nil, qPacketCommitmentSeqsErr(err)
+		}
+		if res.Data != nil {
+			missing = append(missing, seq)
+		}
+	}
+	if len(missing) > 0 {
+		c.Error(fmt.Errorf("- [%s]@{%d} - node at %s left the commitments of packet seqs %v out of the listing",
+			c.ChainID, height, c.ActiveRPCAddr(), missing))
+		checked = append(checked, missing...)
+	}
+
+	sort.Slice(checked, func(i, j int) bool { return checked[i] < checked[j] })
+	return checked, nil
+}
+
+func qPacketCommitmentSeqsErr(err error) error {
+	return fmt.Errorf("query packet commitment sequences failed: %w", err)
+}
+
+// QueryPacketAckAbsence returns the proof that no acknowledgement has been written for a
+// packet with the given seq. On unordered channels this proves that the packet hasn't been received.
+func (c *Chain) QueryPacketAckAbsence(height, seq int64) (comRes CommitmentResponse, err error) {
+	if !c.PathSet() {
+		return comRes, c.ErrPathNotSet()
+	}
+
+	req := abci.RequestQuery{
+		Path:   "store/ibc/key",
+		Data:   ibctypes.KeyPacketAcknowledgement(c.PathEnd.PortID, c.PathEnd.ChannelID, uint64(seq)),
+		Height: height,
+		Prove:  true,
+	}
+
+	res, err := c.QueryABCI(req)
+	if err != nil {
+		return comRes, qPacketAckErr(err)
+	} else if res.Value != nil {
+		return comRes, qPacketAckErr(fmt.Errorf("packet with seq %d has already been received", seq))
+	}
+
+	return CommitmentResponse{
+		Proof: commitmenttypes.MerkleProof{Proof: res.Proof},
+		ProofPath: commitmenttypes.NewMerklePath(
+			strings.Split(
+				string(ibctypes.KeyPacketAcknowledgement(c.PathEnd.PortID, c.PathEnd.ChannelID, uint64(seq))),
+				"/",
+			),
+		),
+		ProofHeight: uint64(res.Height),
+	}, nil
+}
+
+// maxConcurrentAckQueries is the number of acknowledgement queries QueryUnreceivedPackets
+// has in flight at once
+const maxConcurrentAckQueries = 10
+
+// QueryUnreceivedPackets returns the seqs that have no acknowledgement written on the configured
+// channel. On unordered channels this is the set of packets that haven't been received yet.
+// The acknowledgements are queried concurrently at the same height, so their proofs are all
+// verified against one header.
+func (c *Chain) QueryUnreceivedPackets(height int64, seqs []uint64) ([]uint64, error) {
+	var (
+		wg       sync.WaitGroup
+		mtx      sync.Mutex
+		es       = errs{}
+		received = make([]bool, len(seqs))
+		sem      = make(chan struct{}, maxConcurrentAckQueries)
+	)
+
+	for i, seq := range seqs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, seq uint64) {
+			defer func() { <-sem; wg.Done() }()
+			ack, err := c.QueryPacketAck(height, int64(seq))
+			if err != nil {
+				mtx.Lock()
+				es = append(es, err)
+				mtx.Unlock()
+				return
+			}
+			received[i] = ack.Data != nil
+		}(i, seq)
+	}
+	wg.Wait()
+
+	if err := es.err(); err != nil {
+		return nil, err
+	}
+
+	out := []uint64{}
+	for i, seq := range seqs {
+		if !received[i] {
+			out = append(out, seq)
+		}
+	}
+	return out, nil
+}
+
 // PathStatus returns the status of a given path
 type PathStatus struct {
 	Chains       map[string]*ChainStatus `json:"chains" yaml:"chains"`
@@ -826,6 +1045,7 @@ type PathStatus struct {
 type ChainStatus struct {
 	Reachable  bool              `json:"reachable" yaml:"reachable"`
 	Height     int64             `json:"height" yaml:"height"`
+	GasPrices  string            `json:"gas-prices" yaml:"gas-prices"`
 	Client     *ClientStatus     `json:"client" yaml:"client"`
 	Connection *ConnectionStatus `json:"connection" yaml:"connection"`
 	Channel    *ChannelStatus    `json:"channel" yaml:"channel"`
@@ -858,6 +1078,7 @@ func QueryPathStatus(src, dst *Chain, path *Path) (stat *PathStatus, err error)
 			src.ChainID: {
 				Reachable:  false,
 				Height:     -1,
+				GasPrices:  src.EffectiveGasPrices().String(),
 				Client:     &ClientStatus{},
 				Connection: &ConnectionStatus{},
 				Channel:    &ChannelStatus{},
@@ -865,6 +1086,7 @@ func QueryPathStatus(src, dst *Chain, path *Path) (stat *PathStatus, err error)
 			dst.ChainID: {
 				Reachable:  false,
 				Height:     -1,
+				GasPrices:  dst.EffectiveGasPrices().String(),
 				Client:     &ClientStatus{},
 				Connection: &ConnectionStatus{},
 				Channel:    &ChannelStatus{},
@@ -907,21 +1129,21 @@ func QueryPathStatus(src, dst *Chain, path *Path) (stat *PathStatus, err error)
 	stat.Chains[dst.ChainID].Client.ID = dstCs.ClientState.GetID()
 	stat.Chains[dst.ChainID].Client.Height = dstCs.ClientState.GetLatestHeight()
 
-	srcConn, err := src.QueryConnection(int64(sh.GetHeight(src.ChainID)))
+	srcConn, err := src.QueryConnection(int64(sh.GetHeight(src.ChainID) - 1))
 	if err != nil {
 		return
 	}
 	stat.Chains[src.ChainID].Connection.ID = srcConn.Connection.ID
 	stat.Chains[src.ChainID].Connection.State = srcConn.Connection.State.String()
 
-	dstConn, err := dst.QueryConnection(int64(sh.GetHeight(dst.ChainID)))
+	dstConn, err := dst.QueryConnection(int64(sh.GetHeight(dst.ChainID) - 1))
 	if err != nil {
 		return
 	}
 	stat.Chains[dst.ChainID].Connection.ID = dstConn.Connection.ID
 	stat.Chains[dst.ChainID].Connection.State = dstConn.Connection.State.String()
 
-	srcChan, err := src.QueryChannel(int64(sh.GetHeight(src.ChainID)))
+	srcChan, err := src.QueryChannel(int64(sh.GetHeight(src.ChainID) - 1))
 	if err != nil {
 		return
 	}
@@ -930,7 +1152,7 @@ func QueryPathStatus(src, dst *Chain, path *Path) (stat *PathStatus, err error)
 	stat.Chains[src.ChainID].Channel.State = srcChan.Channel.State.String()
 	stat.Chains[src.ChainID].Channel.Order = srcChan.Channel.Ordering.String()
 
-	dstChan, err := dst.QueryChannel(int64(sh.GetHeight(dst.ChainID)))
+	dstChan, err := dst.QueryChannel(int64(sh.GetHeight(dst.ChainID) - 1))
 	if err != nil {
 		return
 	}
@@ -939,7 +1161,12 @@ func QueryPathStatus(src, dst *Chain, path *Path) (stat *PathStatus, err error)
 	stat.Chains[dst.ChainID].Channel.State = dstChan.Channel.State.String()
 	stat.Chains[dst.ChainID].Channel.Order = dstChan.Channel.Ordering.String()
 
-	unrelayed, err := UnrelayedSequences(src, dst, sh)
+	var unrelayed *RelaySequences
+	if path.Ordered() {
+		unrelayed, err = UnrelayedSequences(src, dst, sh)
+	} else {
+		unrelayed, err = UnrelayedSequencesUnordered(src, dst, sh)
+	}
 	if err != nil {
 		return
 	}
@@ -947,19 +1174,31 @@ func QueryPathStatus(src, dst *Chain, path *Path) (stat *PathStatus, err error)
 	return
 }
 
-// QueryTx takes a transaction hash and returns the transaction
+// QueryTx takes a transaction hash and returns the transaction, verified against
+// the lite client
 func (c *Chain) QueryTx(hashHex string) (sdk.TxResponse, error) {
+	return c.queryTx(hashHex, true)
+}
+
+// queryTx returns the transaction with the given hash, verifying it against the
+// lite client if prove is set. Unverified lookups are only used to confirm that
+// a broadcast tx was committed.
+func (c *Chain) queryTx(hashHex string, prove bool) (sdk.TxResponse, error) {
 	hash, err := hex.DecodeString(hashHex)
 	if err != nil {
 		return sdk.TxResponse{}, err
 	}
 
-	resTx, err := c.Client.Tx(hash, true)
+	resTx, err := c.Client.Tx(hash, prove)
 	if err != nil {
 		return sdk.TxResponse{}, err
 	}
 
-	// TODO: validate data coming back with local lite client
+	if prove {
+		if err = c.ValidateTxResult(resTx); err != nil {
+			return sdk.TxResponse{}, err
+		}
+	}
 
 	resBlocks, err := c.queryBlocksForTxResults([]*ctypes.ResultTx{resTx})
 	if err != nil {
@@ -993,12 +1232,11 @@ func (c *Chain) QueryTxs(height uint64, page, limit int, events []string) (*sdk.
 		return nil, err
 	}
 
-	// TODO: Enable lite client validation
-	// for _, tx := range resTxs.Txs {
-	// 	if err = c.ValidateTxResult(tx); err != nil {
-	// 		return nil, err
-	// 	}
-	// }
+	for _, tx := range resTxs.Txs {
+		if err = c.ValidateTxResult(tx); err != nil {
+			return nil, err
+		}
+	}
 
 	resBlocks, err := c.queryBlocksForTxResults(resTxs.Txs)
 	if err != nil {
@@ -1148,14 +1386,11 @@ func (c *Chain) QueryHeaderAtHeight(height int64) (*tmclient.Header, error) {
 	}, nil
 }
 
-// isQueryStoreWithProof expects a format like /<queryType>/<storeName>/<subpath>
-// queryType must be "store" and subpath must be "key" to require a proof.
+// isQueryStoreWithProof expects a format like /<queryType>/<storeName>/<subpath>,
+// where the leading slash is optional. queryType must be "store" and subpath must
+// be "key" to require a proof.
 func isQueryStoreWithProof(path string) bool {
-	if !strings.HasPrefix(path, "/") {
-		return false
-	}
-
-	paths := strings.SplitN(path[1:], "/", 3)
+	paths := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 3)
 	switch {
 	case len(paths) != 3:
 		return false
@@ -1168,6 +1403,15 @@ func isQueryStoreWithProof(path string) bool {
 	return false
 }
 
+// parseQueryStorePath returns the store name of a store query path
+func parseQueryStorePath(path string) (storeName string, err error) {
+	paths := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 3)
+	if len(paths) != 3 || paths[0] != "store" || paths[2] != "key" {
+		return "", fmt.Errorf("expected store key query path, got %s", path)
+	}
+	return paths[1], nil
+}
+
 // queryBlocksForTxResults returns a map[blockHeight]txResult
 func (c *Chain) queryBlocksForTxResults(resTxs []*ctypes.ResultTx) (map[int64]*ctypes.ResultBlock, error) {
 	resBlocks := make(map[int64]*ctypes.ResultBlock)
@@ -0,0 +1,156 @@
+package relayer
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultEventWorkers is the default number of events handled concurrently in
+	// each direction of a path. Handling events one at a time keeps the relay
+	// transactions from colliding on the account sequence.
+	DefaultEventWorkers = 1
+	// DefaultEventQueueSize is the default number of events that can wait to be
+	// handled in each direction of a path
+	DefaultEventQueueSize = 100
+)
+
+// eventQueue is a bounded queue of events that are passed to handle by a fixed
+// number of workers. Events are taken from the queue in the order they were added.
+// Added events are moved into the queue by the queue's own feeder goroutine, which
+// waits while the queue is full, so a full queue never holds up the caller. If drop
+// is set the feeder drops the events instead of waiting.
+type eventQueue struct {
+	events chan map[string][]string
+	wg     sync.WaitGroup
+
+	// events added but not yet moved into the queue by the feeder
+	mu      sync.Mutex
+	backlog []map[string][]string
+	closed  bool
+	ready   chan struct{}
+	waiting int64
+
+	drop    bool
+	dropped uint64
+	// onFull is called by the feeder with the number of events waiting to be moved
+	// into the queue when it finds the queue full, once each time the queue fills
+	// up after having drained
+	onFull func(waiting int)
+	full   bool
+}
+
+func newEventQueue(size, workers int, drop bool, onFull func(waiting int), handle func(map[string][]string)) *eventQueue {
+	q := &eventQueue{
+		events: make(chan map[string][]string, size),
+		ready:  make(chan struct{}, 1),
+		drop:   drop,
+		onFull: onFull,
+	}
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer q.wg.Done()
+			for events := range q.events {
+				handle(events)
+			}
+		}()
+	}
+	go q.feed()
+	return q
+}
+
+// enqueue adds events to the queue without blocking
+func (q *eventQueue) enqueue(events map[string][]string) {
+	q.mu.Lock()
+	q.backlog = append(q.backlog, events)
+	q.mu.Unlock()
+	atomic.AddInt64(&q.waiting, 1)
+	q.signal()
+}
+
+// signal wakes the feeder if it is waiting for events
+func (q *eventQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// feed moves the added events into the queue until it is stopped, then closes it
+func (q *eventQueue) feed() {
+	defer close(q.events)
+	for {
+		q.mu.Lock()
+		backlog, closed := q.backlog, q.closed
+		q.backlog = nil
+		q.mu.Unlock()
+
+		for _, events := range backlog {
+			q.push(events)
+		}
+		switch {
+		case len(backlog) > 0:
+		case closed:
+			return
+		default:
+			<-q.ready
+		}
+	}
+}
+
+// push moves events into the queue, waiting while it is full unless events are
+// dropped from a full queue
+func (q *eventQueue) push(events map[string][]string) {
+	defer atomic.AddInt64(&q.waiting, -1)
+
+	if len(q.events) == 0 {
+		q.full = false
+	}
+	select {
+	case q.events <- events:
+		return
+	default:
+	}
+
+	if !q.full {
+		q.full = true
+		if q.onFull != nil {
+			q.onFull(int(atomic.LoadInt64(&q.waiting)))
+		}
+	}
+	if q.drop {
+		atomic.AddUint64(&q.dropped, 1)
+		return
+	}
+	q.events <- events
+}
+
+// depth returns the number of events waiting in the queue and to be moved into it
+func (q *eventQueue) depth() int {
+	if q == nil {
+		return 0
+	}
+	return len(q.events) + int(atomic.LoadInt64(&q.waiting))
+}
+
+// droppedEvents returns the number of events dropped because the queue was full
+func (q *eventQueue) droppedEvents() uint64 {
+	if q == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&q.dropped)
+}
+
+// stop closes the queue and waits for the workers to handle the remaining events.
+// It must not be called while events are being added.
+func (q *eventQueue) stop() {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.signal()
+	q.wg.Wait()
+}
@@ -0,0 +1,65 @@
+package relayer
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventQueue(t *testing.T) {
+	tests := []struct {
+		name string
+		drop bool
+	}{
+		{"waits for room", false},
+		{"drops events", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				handled []string
+				fills   int
+			)
+			started, block := make(chan struct{}, 10), make(chan struct{})
+			q := newEventQueue(2, 1, tc.drop, func(int) { fills++ }, func(events map[string][]string) {
+				started <- struct{}{}
+				<-block
+				mu.Lock()
+				handled = append(handled, events["seq"][0])
+				mu.Unlock()
+			})
+
+			// the worker holds the first event, so the queue fills up with the next two
+			q.enqueue(map[string][]string{"seq": {"0"}})
+			<-started
+			for i := 1; i < 10; i++ {
+				q.enqueue(map[string][]string{"seq": {strconv.Itoa(i)}})
+			}
+			if tc.drop {
+				require.Eventually(t, func() bool { return q.droppedEvents() == 7 }, time.Second, time.Millisecond)
+			} else {
+				require.Eventually(t, func() bool { return len(q.events) == 2 }, time.Second, time.Millisecond)
+				require.Equal(t, uint64(0), q.droppedEvents())
+			}
+			close(block)
+			q.stop()
+
+			if tc.drop {
+				require.Equal(t, 1, fills, "queue filled up once")
+				require.Equal(t, 10, len(handled)+int(q.droppedEvents()))
+				require.Equal(t, []string{"0", "1", "2"}, handled)
+				return
+			}
+			require.GreaterOrEqual(t, fills, 1)
+			require.Len(t, handled, 10)
+			for i, seq := range handled {
+				require.Equal(t, strconv.Itoa(i), seq, "events are handled in order")
+			}
+		})
+	}
+}
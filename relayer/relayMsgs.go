@@ -3,6 +3,7 @@ package relayer
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
@@ -37,77 +38,153 @@ func (r *RelayMsgs) Success() bool {
 	return r.success
 }
 
+// add appends msg to the list of msgs for chain, which must be either src or dst
+func (r *RelayMsgs) add(src, dst, chain *Chain, msg sdk.Msg) {
+	if chain == dst {
+		r.Dst = append(r.Dst, msg)
+	} else {
+		r.Src = append(r.Src, msg)
+	}
+}
+
 func (r *RelayMsgs) IsMaxTx(msgLen, txSize uint64) bool {
 	return (r.MaxMsgLength != 0 && msgLen > r.MaxMsgLength) ||
 		(r.MaxTxSize != 0 && txSize > r.MaxTxSize)
 }
 
-// Send sends the messages with appropriate output
-// TODO: Parallelize? Maybe?
-func (r *RelayMsgs) Send(src, dst *Chain) {
-	var msgLen, txSize uint64
-	var msgs []sdk.Msg
+// BatchResult is the result of sending a batch of relay msgs to a chain in one tx
+type BatchResult struct {
+	ChainID string
+	Msgs    []sdk.Msg
+	TxHash  string
+	Height  int64
+	Code    uint32
+	Err     error
+}
 
-	r.success = true
+// Success returns true if the batch's tx was committed without error
+func (br *BatchResult) Success() bool {
+	return br.Err == nil && br.Code == 0
+}
 
-	// submit batches of relay transactions
-	for _, msg := range r.Src {
-		msgLen++
-		txSize += uint64(len(msg.GetSignBytes()))
+// SendResult lists the results of each batch sent by RelayMsgs.Send, in the
+// order the batches were sent to each chain
+type SendResult struct {
+	Src []*BatchResult
+	Dst []*BatchResult
+}
 
-		if r.IsMaxTx(msgLen, txSize) {
-			// Submit the transactions to src chain and update its status
-			r.success = r.success && send(src, msgs)
+// SrcSuccess returns true if every batch sent to the src chain succeeded
+func (sr *SendResult) SrcSuccess() bool {
+	return allSucceeded(sr.Src)
+}
 
-			// clear the current batch and reset variables
-			msgLen, txSize = 1, uint64(len(msg.GetSignBytes()))
-			msgs = []sdk.Msg{}
+// DstSuccess returns true if every batch sent to the dst chain succeeded
+func (sr *SendResult) DstSuccess() bool {
+	return allSucceeded(sr.Dst)
+}
+
+// Success returns true if every batch sent to both chains succeeded
+func (sr *SendResult) Success() bool {
+	return sr.SrcSuccess() && sr.DstSuccess()
+}
+
+// Failed returns the batches that didn't succeed
+func (sr *SendResult) Failed() (out []*BatchResult) {
+	for _, br := range append(append([]*BatchResult{}, sr.Src...), sr.Dst...) {
+		if !br.Success() {
+			out = append(out, br)
 		}
-		msgs = append(msgs, msg)
 	}
+	return out
+}
 
-	// submit leftover msgs
-	if len(msgs) > 0 && !send(src, msgs) {
-		r.success = false
+func allSucceeded(results []*BatchResult) bool {
+	for _, br := range results {
+		if !br.Success() {
+			return false
+		}
 	}
+	return true
+}
+
+// Send sends the messages with appropriate output. The src and dst msgs are sent
+// concurrently, while the batches for each chain are sent in order.
+func (r *RelayMsgs) Send(src, dst *Chain) *SendResult {
+	var (
+		wg     sync.WaitGroup
+		result = &SendResult{}
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		result.Src = sendBatches(src, r.batches(r.Src))
+	}()
+	go func() {
+		defer wg.Done()
+		result.Dst = sendBatches(dst, r.batches(r.Dst))
+	}()
+	wg.Wait()
+
+	r.success = result.Success()
+	return result
+}
 
-	// reset variables
-	msgLen, txSize = 0, 0
-	msgs = []sdk.Msg{}
+// batches splits msgs into batches that fit within the max tx size and msg length
+func (r *RelayMsgs) batches(msgs []sdk.Msg) (out [][]sdk.Msg) {
+	var (
+		msgLen, txSize uint64
+		batch          []sdk.Msg
+	)
 
-	for _, msg := range r.Dst {
+	for _, msg := range msgs {
 		msgLen++
 		txSize += uint64(len(msg.GetSignBytes()))
 
-		if r.IsMaxTx(msgLen, txSize) {
-			// Submit the transaction to dst chain and update its status
-			r.success = r.success && send(dst, msgs)
+		if r.IsMaxTx(msgLen, txSize) && len(batch) > 0 {
+			out = append(out, batch)
 
 			// clear the current batch and reset variables
 			msgLen, txSize = 1, uint64(len(msg.GetSignBytes()))
-			msgs = []sdk.Msg{}
+			batch = []sdk.Msg{}
 		}
-		msgs = append(msgs, msg)
+		batch = append(batch, msg)
+	}
+
+	// leftover msgs
+	if len(batch) > 0 {
+		out = append(out, batch)
 	}
+	return out
+}
 
-	// submit leftover msgs
-	if len(msgs) > 0 && !send(dst, msgs) {
-		r.success = false
+// sendBatches sends each batch to the chain in turn, and returns the result of
+// every batch even if earlier batches failed
+func sendBatches(chain *Chain, batches [][]sdk.Msg) (out []*BatchResult) {
+	for _, msgs := range batches {
+		out = append(out, send(chain, msgs))
 	}
+	return out
 }
 
 // Submits the messages to the provided chain and logs the result of the transaction.
-// Returns true upon success and false otherwise.
-func send(chain *Chain, msgs []sdk.Msg) bool {
-	res, err := chain.SendMsgs(msgs)
+func send(chain *Chain, msgs []sdk.Msg) *BatchResult {
+	res, err := chain.sendRelayMsgs(msgs)
 	if err != nil || res.Code != 0 {
 		chain.LogFailedTx(res, err, msgs)
-		return false
 	} else {
 		// NOTE: Add more data to this such as identifiers
 		chain.LogSuccessTx(res, msgs)
 	}
-	return true
+	return &BatchResult{
+		ChainID: chain.ChainID,
+		Msgs:    msgs,
+		TxHash:  res.TxHash,
+		Height:  res.Height,
+		Code:    res.Code,
+		Err:     err,
+	}
 }
 
 func getMsgAction(msgs []sdk.Msg) string {
@@ -1,11 +1,13 @@
 package relayer
 
 import (
+	"bytes"
 	"fmt"
 
 	retry "github.com/avast/retry-go"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	chanTypes "github.com/cosmos/cosmos-sdk/x/ibc/04-channel/types"
+	ibctypes "github.com/cosmos/cosmos-sdk/x/ibc/types"
 )
 
 type relayPacket interface {
@@ -43,12 +45,21 @@ func (rp *relayMsgTimeout) FetchCommitResponse(src, dst *Chain, sh *SyncHeaders)
 
 	// retry getting commit response until it succeeds
 	if err = retry.Do(func() error {
-		// NOTE: Timeouts currently only work with ORDERED channels for nwo
-		dstRecvRes, err = dst.QueryNextSeqRecv(int64(sh.GetHeight(dst.ChainID) - 1))
-		if err != nil {
+		h := int64(sh.GetHeight(dst.ChainID) - 1)
+		if dst.PathEnd.getOrder() == ibctypes.UNORDERED {
+			// unordered channels prove that the packet was never received
+			// with the absence of its acknowledgement
+			var ackRes CommitmentResponse
+			if ackRes, err = dst.QueryPacketAckAbsence(h, int64(rp.seq)); err != nil {
+				return err
+			}
+			dstRecvRes = chanTypes.NewRecvResponse(dst.PathEnd.PortID, dst.PathEnd.ChannelID, rp.seq, ackRes.Proof.Proof, int64(ackRes.ProofHeight))
+		} else if dstRecvRes, err = dst.QueryNextSeqRecv(h); err != nil {
 			return err
-		} else if dstRecvRes.Proof.Proof == nil {
-			return fmt.Errorf("- [%s]@{%d} - Packet Commitment Proof is nil seq(%d)", dst.ChainID, int64(sh.GetHeight(dst.ChainID)-1), rp.seq)
+		}
+
+		if dstRecvRes.Proof.Proof == nil {
+			return fmt.Errorf("- [%s]@{%d} - Packet Commitment Proof is nil seq(%d)", dst.ChainID, h, rp.seq)
 		}
 		return nil
 	}); err != nil {
@@ -126,6 +137,11 @@ func (rp *relayMsgRecvPacket) FetchCommitResponse(src, dst *Chain, sh *SyncHeade
 		return
 	}
 
+	packet := chanTypes.NewPacket(rp.packetData, rp.seq, "", "", "", "", rp.timeout, rp.timeoutStamp)
+	if err = checkCommitment(dst, "packet", rp.seq, chanTypes.CommitPacket(packet), dstCommitRes.Data); err != nil {
+		return
+	}
+
 	rp.dstComRes = &dstCommitRes
 	return
 }
@@ -188,6 +204,20 @@ func (rp *relayMsgPacketAck) FetchCommitResponse(src, dst *Chain, sh *SyncHeader
 		dst.Error(err)
 		return
 	}
+
+	if err = checkCommitment(dst, "acknowledgement", rp.seq, chanTypes.CommitAcknowledgement(rp.ack), dstCommitRes.Data); err != nil {
+		return
+	}
 	rp.dstComRes = &dstCommitRes
 	return nil
 }
+
+// checkCommitment returns an error if the commitment of packet data parsed from the
+// events of a tx doesn't match the proven commitment. Tx proofs don't cover events,
+// so the data is checked against the state of the chain before it is relayed.
+func checkCommitment(c *Chain, kind string, seq uint64, commitment, proven []byte) error {
+	if c.SkipProofVerification || bytes.Equal(commitment, proven) {
+		return nil
+	}
+	return fmt.Errorf("- [%s] - %s data of seq(%d) doesn't match its commitment", c.ChainID, kind, seq)
+}
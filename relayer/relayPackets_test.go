@@ -0,0 +1,43 @@
+package relayer
+
+import (
+	"testing"
+
+	chanTypes "github.com/cosmos/cosmos-sdk/x/ibc/04-channel/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCommitment(t *testing.T) {
+	packet := chanTypes.NewPacket([]byte("packet data"), 1, "", "", "", "", 100, 0)
+	proven := chanTypes.CommitPacket(packet)
+	ack := []byte("ack")
+	provenAck := chanTypes.CommitAcknowledgement(ack)
+
+	tests := []struct {
+		name       string
+		commitment []byte
+		proven     []byte
+		valid      bool
+	}{
+		{"packet", chanTypes.CommitPacket(packet), proven, true},
+		{"altered data", chanTypes.CommitPacket(chanTypes.NewPacket([]byte("other data"), 1, "", "", "", "", 100, 0)), proven, false},
+		{"altered timeout", chanTypes.CommitPacket(chanTypes.NewPacket([]byte("packet data"), 1, "", "", "", "", 101, 0)), proven, false},
+		{"no proven commitment", chanTypes.CommitPacket(packet), nil, false},
+		{"ack", chanTypes.CommitAcknowledgement(ack), provenAck, true},
+		{"altered ack", chanTypes.CommitAcknowledgement([]byte("other ack")), provenAck, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkCommitment(&Chain{ChainID: "ibc0"}, "packet", 1, tc.commitment, tc.proven)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+
+	skip := &Chain{ChainID: "ibc0", SkipProofVerification: true}
+	require.NoError(t, checkCommitment(skip, "packet", 1, chanTypes.CommitPacket(packet), nil))
+}
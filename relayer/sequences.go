@@ -0,0 +1,118 @@
+package relayer
+
+import (
+	"fmt"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+)
+
+// accountSequences hands out the account number and sequence to sign with for each
+// account of a chain, so that concurrent transactions from one key don't reuse a
+// sequence. An account's sequence is fetched from the chain the first time it is used
+// and again after a transaction fails to increment it.
+type accountSequences struct {
+	sync.Mutex
+	accounts map[string]*accountSequence
+}
+
+type accountSequence struct {
+	number, sequence uint64
+	synced           bool
+}
+
+func newAccountSequences() *accountSequences {
+	return &accountSequences{accounts: make(map[string]*accountSequence)}
+}
+
+// next returns the account number and the next unused sequence of the address,
+// calling fetch to query them from the chain if the account isn't synced
+func (as *accountSequences) next(addr sdk.AccAddress, fetch func() (number, sequence uint64, err error)) (uint64, uint64, error) {
+	as.Lock()
+	defer as.Unlock()
+
+	acc, ok := as.accounts[addr.String()]
+	if !ok {
+		acc = &accountSequence{}
+		as.accounts[addr.String()] = acc
+	}
+
+	if !acc.synced {
+		number, sequence, err := fetch()
+		if err != nil {
+			return 0, 0, err
+		}
+		acc.number, acc.sequence, acc.synced = number, sequence, true
+	}
+
+	seq := acc.sequence
+	acc.sequence++
+	return acc.number, seq, nil
+}
+
+// resync marks the address's sequence to be fetched from the chain on its next use
+func (as *accountSequences) resync(addr sdk.AccAddress) {
+	as.Lock()
+	defer as.Unlock()
+	if acc, ok := as.accounts[addr.String()]; ok {
+		acc.synced = false
+	}
+}
+
+// nextSequence returns the account number and sequence to sign the next tx from addr with.
+// The sequences are created in Init, as txs are sent from several goroutines.
+func (src *Chain) nextSequence(addr sdk.AccAddress) (uint64, uint64, error) {
+	return src.sequences.next(addr, func() (uint64, uint64, error) {
+		done := src.UseSDKContext()
+		defer done()
+
+		acc, err := auth.NewAccountRetriever(src.Cdc, src).GetAccount(addr)
+		if err != nil {
+			return 0, 0, err
+		}
+		return acc.GetAccountNumber(), acc.GetSequence(), nil
+	})
+}
+
+// sendWithSequence signs a tx from addr with build using the next local sequence of
+// the account and broadcasts it. If the tx doesn't make it past CheckTx its sequence
+// was never used, so the account is resynced, and a tx rejected for an incorrect
+// sequence is built and sent once more.
+func (src *Chain) sendWithSequence(addr sdk.AccAddress, build func(accNum, seq uint64) ([]byte, error)) (res sdk.TxResponse, err error) {
+	for attempt := 0; ; attempt++ {
+		accNum, seq, err := src.nextSequence(addr)
+		if err != nil {
+			return res, err
+		}
+
+		out, err := build(accNum, seq)
+		if err != nil {
+			src.sequences.resync(addr)
+			return res, err
+		}
+
+		if res, err = src.Broadcast(out); err != nil {
+			src.sequences.resync(addr)
+			return res, err
+		}
+
+		// a tx rejected in CheckTx isn't included in a block
+		if res.Code != 0 && res.Height == 0 {
+			src.sequences.resync(addr)
+			if isSequenceMismatch(res) && attempt == 0 {
+				src.Log(fmt.Sprintf("- [%s] sequence %d of %s rejected, resyncing", src.ChainID, seq, addr))
+				continue
+			}
+		}
+		return res, nil
+	}
+}
+
+// isSequenceMismatch returns true if the tx was rejected because it was signed with
+// the wrong sequence, which the sdk reports as a signature verification failure
+func isSequenceMismatch(res sdk.TxResponse) bool {
+	return res.Codespace == sdkerrors.RootCodespace &&
+		(res.Code == sdkerrors.ErrUnauthorized.ABCICode() || res.Code == sdkerrors.ErrInvalidSequence.ABCICode())
+}
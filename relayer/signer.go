@@ -0,0 +1,375 @@
+package relayer
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	keys "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/tendermint/tendermint/crypto"
+	cryptoamino "github.com/tendermint/tendermint/crypto/encoding/amino"
+)
+
+const (
+	// signerServiceName is the name the signer rpc service is registered under
+	signerServiceName = "Signer"
+	// defaultSignerTimeout is used for calls to a remote signer when no timeout is set
+	defaultSignerTimeout = 10 * time.Second
+)
+
+// Signer signs txs with the keys of a chain. The chain's keyring is used when
+// no remote signer is configured.
+type Signer interface {
+	// PubKey returns the public key of the named key
+	PubKey(keyName string) (crypto.PubKey, error)
+	// Sign signs msg with the named key and returns the signature and public key
+	Sign(keyName string, msg []byte) ([]byte, crypto.PubKey, error)
+}
+
+// keyringSigner signs with the keys held in a local keyring
+type keyringSigner struct {
+	kb keys.Keyring
+}
+
+// NewKeyringSigner returns a Signer for the keys held in the keyring
+func NewKeyringSigner(kb keys.Keyring) Signer {
+	return keyringSigner{kb}
+}
+
+func (ks keyringSigner) PubKey(keyName string) (crypto.PubKey, error) {
+	info, err := ks.kb.Key(keyName)
+	if err != nil {
+		return nil, err
+	}
+	return info.GetPubKey(), nil
+}
+
+func (ks keyringSigner) Sign(keyName string, msg []byte) ([]byte, crypto.PubKey, error) {
+	return ks.kb.Sign(keyName, msg)
+}
+
+// GetSigner returns the signer that signs the chain's txs
+func (src *Chain) GetSigner() Signer {
+	return src.signer
+}
+
+// SetSigner replaces the signer that signs the chain's txs
+func (src *Chain) SetSigner(s Signer) {
+	src.signer = s
+	src.address = nil
+}
+
+// KeyAddress returns the address of the named key as reported by the chain's signer
+func (src *Chain) KeyAddress(keyName string) (sdk.AccAddress, error) {
+	pubKey, err := src.signer.PubKey(keyName)
+	if err != nil {
+		return nil, err
+	}
+	return sdk.AccAddress(pubKey.Address()), nil
+}
+
+// signTx builds the tx described by txBldr, has the chain's signer sign it
+// with the named key and encodes it
+func (src *Chain) signTx(txBldr auth.TxBuilder, keyName string, msgs []sdk.Msg) ([]byte, error) {
+	signMsg, err := txBldr.BuildSignMsg(msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, pubKey, err := src.signer.Sign(keyName, signMsg.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign tx with key %s on chain %s: %w", keyName, src.ChainID, err)
+	}
+
+	return txBldr.TxEncoder()(auth.NewStdTx(signMsg.Msgs, signMsg.Fee,
+		[]auth.StdSignature{{PubKey: pubKey.Bytes(), Signature: sig}}, signMsg.Memo))
+}
+
+// errSignerUnauthorized is returned by signer daemons for requests without their token
+var errSignerUnauthorized = errors.New("unauthorized")
+
+// SignRequest asks a signer daemon to sign SignBytes with a key of a chain
+type SignRequest struct {
+	ChainID   string `json:"chain-id"`
+	KeyName   string `json:"key-name"`
+	SignBytes []byte `json:"sign-bytes"`
+	Token     string `json:"token,omitempty"`
+}
+
+// SignResponse holds the signature and the amino encoded public key of the signing key
+type SignResponse struct {
+	Signature []byte `json:"signature"`
+	PubKey    []byte `json:"pub-key"`
+}
+
+// PubKeyRequest asks a signer daemon for the public key of a key of a chain
+type PubKeyRequest struct {
+	ChainID string `json:"chain-id"`
+	KeyName string `json:"key-name"`
+	Token   string `json:"token,omitempty"`
+}
+
+// PubKeyResponse holds the amino encoded public key of a key
+type PubKeyResponse struct {
+	PubKey []byte `json:"pub-key"`
+}
+
+// remoteSigner asks a signer daemon listening on a unix or tcp socket to sign
+// txs, so that the chain's private keys never leave the daemon's host
+type remoteSigner struct {
+	network, addr string
+	chainID       string
+	token         string
+	timeout       time.Duration
+
+	mu     sync.Mutex
+	client *rpc.Client
+}
+
+// NewRemoteSigner returns a Signer for the keys of a chain served by the signer
+// daemon at addr, which is either unix:///path/to/socket or tcp://host:port on a
+// loopback interface. token authenticates the requests and is required by tcp daemons.
+func NewRemoteSigner(addr, chainID, token string, timeout time.Duration) (Signer, error) {
+	network, address, err := parseSignerAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	if network == "tcp" && token == "" {
+		return nil, fmt.Errorf("signer at %s listens on tcp and needs a token", addr)
+	}
+	if timeout <= 0 {
+		timeout = defaultSignerTimeout
+	}
+	return &remoteSigner{network: network, addr: address, chainID: chainID, token: token, timeout: timeout}, nil
+}
+
+// ReadSignerToken reads the token that authenticates requests to a signer daemon
+// from a file. An empty path returns no token.
+func ReadSignerToken(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	bz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read signer token file: %w", err)
+	}
+	token := strings.TrimSpace(string(bz))
+	if token == "" {
+		return "", fmt.Errorf("signer token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// parseSignerAddr splits a signer address into the network and address to dial or listen on
+func parseSignerAddr(addr string) (network, address string, err error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid signer address (%s): %w", addr, err)
+	}
+	switch {
+	case u.Scheme == "unix" && u.Path != "":
+		return u.Scheme, u.Path, nil
+	case u.Scheme == "tcp" && u.Host != "":
+		// requests and their token aren't encrypted, so they must not leave the host
+		if !isLoopback(u.Hostname()) {
+			return "", "", fmt.Errorf("signer address (%s) must be on a loopback interface, "+
+				"forward it over ssh or a unix socket to reach a signer on another host", addr)
+		}
+		return u.Scheme, u.Host, nil
+	default:
+		return "", "", fmt.Errorf("invalid signer address (%s), must be unix:///path/to/socket or tcp://host:port", addr)
+	}
+}
+
+// isLoopback returns true if host is localhost or a loopback ip
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (rs *remoteSigner) PubKey(keyName string) (crypto.PubKey, error) {
+	var res PubKeyResponse
+	if err := rs.call("PubKey", PubKeyRequest{ChainID: rs.chainID, KeyName: keyName, Token: rs.token}, &res); err != nil {
+		return nil, err
+	}
+	return cryptoamino.PubKeyFromBytes(res.PubKey)
+}
+
+func (rs *remoteSigner) Sign(keyName string, msg []byte) ([]byte, crypto.PubKey, error) {
+	var res SignResponse
+	req := SignRequest{ChainID: rs.chainID, KeyName: keyName, SignBytes: msg, Token: rs.token}
+	if err := rs.call("Sign", req, &res); err != nil {
+		return nil, nil, err
+	}
+
+	pubKey, err := cryptoamino.PubKeyFromBytes(res.PubKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// a signature that doesn't verify would only be rejected by the chain after
+	// the tx has been broadcast, so catch it here
+	if !pubKey.VerifyBytes(msg, res.Signature) {
+		return nil, nil, fmt.Errorf("signer at %s returned an invalid signature for key %s", rs.addr, keyName)
+	}
+	return res.Signature, pubKey, nil
+}
+
+// call makes an rpc call to the signer daemon, dialing it if there is no open
+// connection. The connection is dropped on any error that isn't returned by the
+// daemon itself, so that the next call redials.
+func (rs *remoteSigner) call(method string, args, reply interface{}) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.client == nil {
+		conn, err := net.DialTimeout(rs.network, rs.addr, rs.timeout)
+		if err != nil {
+			return fmt.Errorf("failed to connect to signer at %s: %w", rs.addr, err)
+		}
+		rs.client = jsonrpc.NewClient(conn)
+	}
+
+	var err error
+	select {
+	case c := <-rs.client.Go(signerServiceName+"."+method, args, reply, make(chan *rpc.Call, 1)).Done:
+		err = c.Error
+	case <-time.After(rs.timeout):
+		err = fmt.Errorf("timed out after %s", rs.timeout)
+	}
+
+	if _, ok := err.(rpc.ServerError); err != nil && !ok {
+		_ = rs.client.Close()
+		rs.client = nil
+	}
+	if err != nil {
+		return fmt.Errorf("signer at %s failed to handle %s request: %w", rs.addr, method, err)
+	}
+	return nil
+}
+
+// SignerService is the rpc service served by signer daemons. It signs with the
+// signers of the chains it was created with, using only the allowed keys of each.
+type SignerService struct {
+	signers map[string]Signer
+	keys    map[string]map[string]bool
+	token   string
+}
+
+// NewSignerService returns a SignerService for the signers keyed by chain-id,
+// which only signs with the key names listed for each chain in keys. A token
+// is required in every request if it isn't empty.
+func NewSignerService(signers map[string]Signer, keys map[string][]string, token string) *SignerService {
+	allowed := make(map[string]map[string]bool, len(keys))
+	for chainID, names := range keys {
+		allowed[chainID] = make(map[string]bool, len(names))
+		for _, name := range names {
+			allowed[chainID][name] = true
+		}
+	}
+	return &SignerService{signers: signers, keys: allowed, token: token}
+}
+
+// signer returns the signer of a chain after checking the request's token and key
+func (s *SignerService) signer(chainID, keyName, token string) (Signer, error) {
+	if s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		return nil, errSignerUnauthorized
+	}
+	signer, ok := s.signers[chainID]
+	if !ok {
+		return nil, fmt.Errorf("no keys served for chain %s", chainID)
+	}
+	if !s.keys[chainID][keyName] {
+		return nil, fmt.Errorf("key %s is not served for chain %s", keyName, chainID)
+	}
+	return signer, nil
+}
+
+// Sign signs the sign bytes of a request
+func (s *SignerService) Sign(req SignRequest, res *SignResponse) error {
+	signer, err := s.signer(req.ChainID, req.KeyName, req.Token)
+	if err != nil {
+		return err
+	}
+
+	sig, pubKey, err := signer.Sign(req.KeyName, req.SignBytes)
+	if err != nil {
+		return err
+	}
+
+	res.Signature, res.PubKey = sig, pubKey.Bytes()
+	return nil
+}
+
+// PubKey returns the public key of the key of a request
+func (s *SignerService) PubKey(req PubKeyRequest, res *PubKeyResponse) error {
+	signer, err := s.signer(req.ChainID, req.KeyName, req.Token)
+	if err != nil {
+		return err
+	}
+
+	pubKey, err := signer.PubKey(req.KeyName)
+	if err != nil {
+		return err
+	}
+
+	res.PubKey = pubKey.Bytes()
+	return nil
+}
+
+// ListenSigner listens on a signer address, which is either unix:///path/to/socket
+// or tcp://host:port on a loopback interface. A unix socket is only accessible to
+// its owner, and a tcp address can only be listened on with a token to
+// authenticate requests.
+func ListenSigner(addr, token string) (net.Listener, error) {
+	network, address, err := parseSignerAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	if network == "tcp" && token == "" {
+		return nil, fmt.Errorf("a token is required to serve keys on %s", addr)
+	}
+
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		if err = os.Chmod(address, 0600); err != nil {
+			_ = l.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// ServeSigner serves the SignerService on the listener until it is closed. It is
+// used by the signer daemon and can be run in process as a stand-in for one.
+func ServeSigner(l net.Listener, service *SignerService) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(signerServiceName, service); err != nil {
+		return err
+	}
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
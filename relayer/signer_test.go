@@ -0,0 +1,125 @@
+package relayer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	keys "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/stretchr/testify/require"
+)
+
+// serveTestSigner serves the keys of an in memory keyring for chain ibc0 on a unix
+// socket and returns the socket's address and the keyring
+func serveTestSigner(t *testing.T, token string, served ...string) (string, keys.Keyring) {
+	dir, err := ioutil.TempDir("", "relayer-signer")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	kb := keys.NewInMemory()
+	for _, name := range []string{"testkey", "otherkey"} {
+		_, _, err = kb.NewMnemonic(name, keys.English, hd.CreateHDPath(DefaultCoinType, 0, 0).String(), hd.Secp256k1)
+		require.NoError(t, err)
+	}
+
+	addr := "unix://" + filepath.Join(dir, "signer.sock")
+	l, err := ListenSigner(addr, token)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	service := NewSignerService(map[string]Signer{"ibc0": NewKeyringSigner(kb)}, map[string][]string{"ibc0": served}, token)
+	go func() { _ = ServeSigner(l, service) }()
+	return addr, kb
+}
+
+func TestRemoteSignerRoundTrip(t *testing.T) {
+	addr, kb := serveTestSigner(t, "", "testkey")
+
+	fi, err := os.Stat(addr[len("unix://"):])
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	rs, err := NewRemoteSigner(addr, "ibc0", "", time.Second)
+	require.NoError(t, err)
+
+	info, err := kb.Key("testkey")
+	require.NoError(t, err)
+
+	pubKey, err := rs.PubKey("testkey")
+	require.NoError(t, err)
+	require.Equal(t, info.GetPubKey(), pubKey)
+
+	msg := []byte("sign bytes")
+	sig, pubKey, err := rs.Sign("testkey", msg)
+	require.NoError(t, err)
+	require.Equal(t, info.GetPubKey(), pubKey)
+	require.True(t, pubKey.VerifyBytes(msg, sig))
+
+	// keys that aren't served and chains without keys are refused
+	_, _, err = rs.Sign("otherkey", msg)
+	require.Error(t, err)
+	_, err = rs.PubKey("otherkey")
+	require.Error(t, err)
+
+	other, err := NewRemoteSigner(addr, "ibc1", "", time.Second)
+	require.NoError(t, err)
+	_, _, err = other.Sign("testkey", msg)
+	require.Error(t, err)
+}
+
+func TestRemoteSignerToken(t *testing.T) {
+	addr, _ := serveTestSigner(t, "secret", "testkey")
+
+	rs, err := NewRemoteSigner(addr, "ibc0", "secret", time.Second)
+	require.NoError(t, err)
+	_, _, err = rs.Sign("testkey", []byte("sign bytes"))
+	require.NoError(t, err)
+
+	for _, token := range []string{"", "wrong"} {
+		rs, err = NewRemoteSigner(addr, "ibc0", token, time.Second)
+		require.NoError(t, err)
+		_, _, err = rs.Sign("testkey", []byte("sign bytes"))
+		require.Error(t, err)
+	}
+}
+
+func TestSignerTCPRequiresToken(t *testing.T) {
+	_, err := ListenSigner("tcp://127.0.0.1:0", "")
+	require.Error(t, err)
+	_, err = NewRemoteSigner("tcp://127.0.0.1:26659", "ibc0", "", time.Second)
+	require.Error(t, err)
+
+	l, err := ListenSigner("tcp://127.0.0.1:0", "secret")
+	require.NoError(t, err)
+	l.Close()
+}
+
+func TestSignerTCPLoopbackOnly(t *testing.T) {
+	tests := []struct {
+		addr string
+		ok   bool
+	}{
+		{"tcp://127.0.0.1:26659", true},
+		{"tcp://localhost:26659", true},
+		{"tcp://[::1]:26659", true},
+		{"tcp://0.0.0.0:26659", false},
+		{"tcp://:26659", false},
+		{"tcp://10.0.0.5:26659", false},
+		{"tcp://signer.example.com:26659", false},
+		{"unix:///tmp/signer.sock", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.addr, func(t *testing.T) {
+			_, err := NewRemoteSigner(tc.addr, "ibc0", "secret", time.Second)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package relayer
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"sync"
 
-	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"gopkg.in/yaml.v2"
 )
 
 var (
@@ -22,6 +24,68 @@ type Strategy interface {
 	RelayPacketsUnorderedChan(src, dst *Chain, sp *RelaySequences, sh *SyncHeaders) error
 }
 
+// BatchedStrategy is implemented by strategies that bundle relay msgs into transactions
+// and allows the limits on the size (in bytes) and number of msgs of those transactions
+// to be read and set
+type BatchedStrategy interface {
+	Strategy
+	BatchLimits() (maxTxSize, maxMsgLength uint64)
+	SetBatchLimits(maxTxSize, maxMsgLength uint64)
+}
+
+// StrategyConstructor builds a strategy from a path's strategy config. The
+// config's Options have already been decoded by the strategy's options decoder.
+type StrategyConstructor func(cfg *StrategyCfg) (Strategy, error)
+
+// StrategyOptionsDecoder decodes the options section of a strategy config into the
+// strategy's options type. unmarshal decodes the raw options into the value passed to
+// it and leaves the value untouched if the config has no options.
+type StrategyOptionsDecoder func(unmarshal func(interface{}) error) (interface{}, error)
+
+type strategyEntry struct {
+	constructor StrategyConstructor
+	decoder     StrategyOptionsDecoder
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = make(map[string]strategyEntry)
+)
+
+// RegisterStrategy makes a strategy available to paths under the given type name.
+// decoder may be nil for strategies that take no options. It panics if the type is
+// registered twice or the constructor is nil.
+func RegisterStrategy(strategyType string, constructor StrategyConstructor, decoder StrategyOptionsDecoder) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	if constructor == nil {
+		panic(fmt.Sprintf("strategy %s registered with a nil constructor", strategyType))
+	}
+	if _, ok := strategies[strategyType]; ok {
+		panic(fmt.Sprintf("strategy %s registered twice", strategyType))
+	}
+	strategies[strategyType] = strategyEntry{constructor, decoder}
+}
+
+// RegisteredStrategies returns the sorted type names of the registered strategies
+func RegisteredStrategies() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	out := make([]string, 0, len(strategies))
+	for t := range strategies {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func getStrategyEntry(strategyType string) (strategyEntry, bool) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	e, ok := strategies[strategyType]
+	return e, ok
+}
+
 // MustGetStrategy returns the strategy and panics on error
 func (r *Path) MustGetStrategy() Strategy {
 	strat, err := r.GetStrategy()
@@ -33,136 +97,110 @@ func (r *Path) MustGetStrategy() Strategy {
 
 // GetStrategy the strategy defined in the relay messages
 func (r *Path) GetStrategy() (Strategy, error) {
-	switch r.Strategy.Type {
-	case (&NaiveStrategy{}).GetType():
-		return &NaiveStrategy{}, nil
-	default:
-		return nil, fmt.Errorf("invalid strategy: %s", r.Strategy.Type)
-	}
+	return r.Strategy.New()
 }
 
-// StrategyCfg defines which relaying strategy to take for a given path
+// StrategyCfg defines which relaying strategy to take for a given path. Options holds
+// the strategy specific options, decoded into the type returned by the options decoder
+// the strategy was registered with. Workers, QueueSize and DropEvents configure the
+// queues of events handled by the strategy in each direction of the path. ClientRefresh is the
+// fraction of a client's trusting period after which it is updated when idle.
 type StrategyCfg struct {
-	Type string `json:"type" yaml:"type"`
+	Type          string        `json:"type" yaml:"type"`
+	Filter        *PacketFilter `json:"filter,omitempty" yaml:"filter,omitempty"`
+	Workers       int           `json:"workers,omitempty" yaml:"workers,omitempty"`
+	QueueSize     int           `json:"queue-size,omitempty" yaml:"queue-size,omitempty"`
+	DropEvents    bool          `json:"drop-events,omitempty" yaml:"drop-events,omitempty"`
+	ClientRefresh float64       `json:"client-refresh,omitempty" yaml:"client-refresh,omitempty"`
+	Options       interface{}   `json:"options,omitempty" yaml:"options,omitempty"`
 }
 
-// RunStrategy runs a given strategy
-func RunStrategy(src, dst *Chain, strategy Strategy, ordered bool) (func(), error) {
-	doneChan := make(chan struct{})
+// strategyCfg has the fields of StrategyCfg without its unmarshal methods
+type strategyCfg StrategyCfg
 
-	// Fetch latest headers for each chain and store them in sync headers
-	sh, err := NewSyncHeaders(src, dst)
-	if err != nil {
-		return nil, err
+// New builds the strategy registered for the config's type
+func (cfg *StrategyCfg) New() (Strategy, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("strategy not set")
 	}
-
-	// Next start the goroutine that listens to each chain for block and tx events
-	go relayerListenLoop(src, dst, doneChan, sh, strategy)
-
-	// Fetch any unrelayed sequences depending on the channel order
-	var sp *RelaySequences
-	if ordered {
-		sp, err = strategy.UnrelayedSequencesOrdered(src, dst, sh)
-	} else {
-		sp, err = strategy.UnrelayedSequencesUnordered(src, dst, sh)
+	e, ok := getStrategyEntry(cfg.Type)
+	if !ok {
+		return nil, fmt.Errorf("invalid strategy: %s", cfg.Type)
 	}
-
-	if err != nil {
-		return nil, err
+	if cfg.ClientRefresh < 0 || cfg.ClientRefresh >= 1 {
+		return nil, fmt.Errorf("invalid client-refresh (%v), must be between 0 and 1", cfg.ClientRefresh)
 	}
+	return e.constructor(cfg)
+}
 
-	// Relay any packets that remain to be relayed depending on order
-	if ordered {
-		err = strategy.RelayPacketsOrderedChan(src, dst, sp, sh)
-	} else {
-		err = strategy.RelayPacketsUnorderedChan(src, dst, sp, sh)
+// decodeOptions runs the registered options decoder for the config's type. Options of
+// strategies that aren't registered are left in their raw form.
+func (cfg *StrategyCfg) decodeOptions(unmarshal func(interface{}) error) error {
+	e, ok := getStrategyEntry(cfg.Type)
+	if !ok || e.decoder == nil {
+		return unmarshal(&cfg.Options)
 	}
-
+	opts, err := e.decoder(unmarshal)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to decode %s strategy options: %w", cfg.Type, err)
 	}
-
-	// Return a function to stop the relayer goroutine
-	return func() { doneChan <- struct{}{} }, nil
+	cfg.Options = opts
+	return nil
 }
 
-func relayerListenLoop(src, dst *Chain, doneChan chan struct{}, sh *SyncHeaders, strategy Strategy) {
-	var (
-		srcTxEvents, srcBlockEvents, dstTxEvents, dstBlockEvents <-chan ctypes.ResultEvent
-		srcTxCancel, srcBlockCancel, dstTxCancel, dstBlockCancel context.CancelFunc
-		err                                                      error
-	)
-
-	// Start client for source chain
-	if err = src.Start(); err != nil {
-		src.Error(err)
-		return
+// ResolvedOptions returns the options the strategy runs with, which are the options
+// decoder's defaults if the config has no options
+func (cfg *StrategyCfg) ResolvedOptions() (interface{}, error) {
+	if cfg.Options != nil {
+		return cfg.Options, nil
 	}
-
-	// Subscibe to txEvents from the source chain
-	if srcTxEvents, srcTxCancel, err = src.Subscribe(txEvents); err != nil {
-		src.Error(err)
-		return
-	}
-	defer srcTxCancel()
-	src.Log(fmt.Sprintf("- listening to tx events from %s...", src.ChainID))
-
-	// Subscibe to blockEvents from the source chain
-	if srcBlockEvents, srcBlockCancel, err = src.Subscribe(blEvents); err != nil {
-		src.Error(err)
-		return
+	e, ok := getStrategyEntry(cfg.Type)
+	if !ok || e.decoder == nil {
+		return nil, nil
 	}
-	defer srcBlockCancel()
-	src.Log(fmt.Sprintf("- listening to block events from %s...", src.ChainID))
+	return e.decoder(func(interface{}) error { return nil })
+}
 
-	// Subscribe to destination chain
-	if err = dst.Start(); err != nil {
-		dst.Error(err)
-		return
+// UnmarshalYAML implements yaml.Unmarshaler so that the options are decoded by the strategy
+func (cfg *StrategyCfg) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw strategyCfg
+	if err := unmarshal(&raw); err != nil {
+		return err
 	}
+	*cfg = StrategyCfg(raw)
+	cfg.Options = nil
+	return cfg.decodeOptions(func(v interface{}) error {
+		if raw.Options == nil {
+			return nil
+		}
+		bz, err := yaml.Marshal(raw.Options)
+		if err != nil {
+			return err
+		}
+		return yaml.Unmarshal(bz, v)
+	})
+}
 
-	// Subscibe to txEvents from the destination chain
-	if dstTxEvents, dstTxCancel, err = dst.Subscribe(txEvents); err != nil {
-		dst.Error(err)
-		return
+// UnmarshalJSON implements json.Unmarshaler so that the options are decoded by the strategy
+func (cfg *StrategyCfg) UnmarshalJSON(bz []byte) error {
+	var raw struct {
+		*strategyCfg
+		Options json.RawMessage `json:"options,omitempty"`
 	}
-	defer dstTxCancel()
-	dst.Log(fmt.Sprintf("- listening to tx events from %s...", dst.ChainID))
-
-	// Subscibe to blockEvents from the destination chain
-	if dstBlockEvents, dstBlockCancel, err = dst.Subscribe(blEvents); err != nil {
-		src.Error(err)
-		return
+	raw.strategyCfg = (*strategyCfg)(cfg)
+	if err := json.Unmarshal(bz, &raw); err != nil {
+		return err
 	}
-	defer dstBlockCancel()
-	dst.Log(fmt.Sprintf("- listening to block events from %s...", dst.ChainID))
-
-	// Listen to channels and take appropriate action
-	for {
-		select {
-		case srcMsg := <-srcTxEvents:
-			src.logTx(srcMsg.Events)
-			go strategy.HandleEvents(dst, src, sh, srcMsg.Events)
-		case dstMsg := <-dstTxEvents:
-			dst.logTx(dstMsg.Events)
-			go strategy.HandleEvents(src, dst, sh, dstMsg.Events)
-		case srcMsg := <-srcBlockEvents:
-			// TODO: Add debug block logging here
-			if err = sh.Update(src); err != nil {
-				src.Error(err)
-			}
-			go strategy.HandleEvents(dst, src, sh, srcMsg.Events)
-		case dstMsg := <-dstBlockEvents:
-			// TODO: Add debug block logging here
-			if err = sh.Update(dst); err != nil {
-				dst.Error(err)
-			}
-			go strategy.HandleEvents(src, dst, sh, dstMsg.Events)
-		case <-doneChan:
-			src.Log(fmt.Sprintf("- [%s]:{%s} <-> [%s]:{%s} relayer shutting down",
-				src.ChainID, src.PathEnd.PortID, dst.ChainID, dst.PathEnd.PortID))
-			close(doneChan)
-			return
+	cfg.Options = nil
+	return cfg.decodeOptions(func(v interface{}) error {
+		if len(raw.Options) == 0 || string(raw.Options) == "null" {
+			return nil
 		}
-	}
+		return json.Unmarshal(raw.Options, v)
+	})
+}
+
+// RunStrategy runs a given strategy
+func RunStrategy(src, dst *Chain, strategy Strategy, ordered bool) (func(), error) {
+	return RunStrategies(&RelayPath{Src: src, Dst: dst, Strategy: strategy, Ordered: ordered})
 }
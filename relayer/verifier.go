@@ -1,25 +1,24 @@
 package relayer
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tmclient "github.com/cosmos/cosmos-sdk/x/ibc/07-tendermint/types"
-	dbm "github.com/tendermint/tm-db"
 
 	retry "github.com/avast/retry-go"
+	"github.com/cosmos/cosmos-sdk/store/rootmulti"
 	abci "github.com/tendermint/tendermint/abci/types"
-	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/crypto/merkle"
 	lite "github.com/tendermint/tendermint/lite2"
-	litep "github.com/tendermint/tendermint/lite2/provider"
-	litehttp "github.com/tendermint/tendermint/lite2/provider/http"
-	dbs "github.com/tendermint/tendermint/lite2/store/db"
+	litestore "github.com/tendermint/tendermint/lite2/store"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
 )
 
 type header struct {
@@ -61,117 +60,111 @@ func liteError(err error) error { return fmt.Errorf("lite client: %w", err) }
 
 // UpdateLiteWithHeader calls client.Update and then .
 func (c *Chain) UpdateLiteWithHeader() (*tmclient.Header, error) {
-	// create database connection
-	db, df, err := c.NewLiteDB()
-	if err != nil {
+	if err := c.liteDiverged(); err != nil {
 		return nil, liteError(err)
 	}
-	defer df()
 
-	client, err := c.LiteClientWithoutTrust(db)
-	if err != nil {
-		return nil, liteError(err)
-	}
+	var out *tmclient.Header
+	err := c.withLiteClient(func(client *lite.Client) error {
+		sh, err := client.Update(time.Now())
+		if err != nil {
+			return c.checkDivergence(err)
+		}
 
-	sh, err := client.Update(time.Now())
-	if err != nil {
-		return nil, liteError(err)
-	}
+		if sh == nil {
+			sh, err = client.TrustedHeader(0)
+			if err != nil {
+				return err
+			}
+		}
 
-	if sh == nil {
-		sh, err = client.TrustedHeader(0)
+		vs, _, err := client.TrustedValidatorSet(sh.Height)
 		if err != nil {
-			return nil, liteError(err)
+			return err
 		}
-	}
 
-	vs, _, err := client.TrustedValidatorSet(sh.Height)
+		out = &tmclient.Header{SignedHeader: *sh, ValidatorSet: vs}
+		return nil
+	})
 	if err != nil {
 		return nil, liteError(err)
 	}
-
-	return &tmclient.Header{SignedHeader: *sh, ValidatorSet: vs}, nil
+	c.heights.observe(out.Header.Height)
+	return out, nil
 }
 
 func (c *Chain) UpdateLiteWithHeaderHeight(height int64) (*tmclient.Header, error) {
-	// create database connection
-	db, df, err := c.NewLiteDB()
-	if err != nil {
+	if err := c.liteDiverged(); err != nil {
 		return nil, err
 	}
-	defer df()
 
-	client, err := c.LiteClientWithoutTrust(db)
-	if err != nil {
-		return nil, err
-	}
-
-	sh, err := client.VerifyHeaderAtHeight(height, time.Now())
-	if err != nil {
-		return nil, err
-	}
+	var out *tmclient.Header
+	err := c.withLiteClient(func(client *lite.Client) error {
+		sh, err := client.VerifyHeaderAtHeight(height, time.Now())
+		if err != nil {
+			return c.checkDivergence(err)
+		}
 
-	vs, _, err := client.TrustedValidatorSet(sh.Height)
-	if err != nil {
-		return nil, err
-	}
+		vs, _, err := client.TrustedValidatorSet(sh.Height)
+		if err != nil {
+			return err
+		}
 
-	return &tmclient.Header{SignedHeader: *sh, ValidatorSet: vs}, nil
+		out = &tmclient.Header{SignedHeader: *sh, ValidatorSet: vs}
+		return nil
+	})
+	return out, err
 }
 
 // LiteClientWithoutTrust reads the trusted period off of the chain.
-func (c *Chain) LiteClientWithoutTrust(db *dbm.GoLevelDB) (*lite.Client, error) {
-	httpProvider, err := litehttp.New(c.ChainID, c.RPCAddr)
+func (c *Chain) LiteClientWithoutTrust(store litestore.Store) (*lite.Client, error) {
+	httpProvider, err := c.liteProvider()
 	if err != nil {
 		return nil, err
 	}
 
-	// NOTE: currently we are discarding the very noisy lite client logs
-	// it would be nice if we could add a setting the chain or otherwise
-	// that allowed users to enable lite client logging. (maybe as a hidden prop
-	// on the Chain struct that users could pass in the config??)
-	logger := log.NewTMLogger(log.NewSyncWriter(ioutil.Discard))
+	witnesses, err := c.liteWitnesses(httpProvider)
+	if err != nil {
+		return nil, err
+	}
 
-	// TODO: provide actual witnesses!
 	return lite.NewClientFromTrustedStore(c.ChainID, c.GetTrustingPeriod(), httpProvider,
-		[]litep.Provider{httpProvider}, dbs.New(db, ""),
-		lite.Logger(logger))
+		witnesses, store, c.liteClientOptions()...)
 }
 
 // LiteClient initializes the lite client for a given chain.
-func (c *Chain) LiteClient(db *dbm.GoLevelDB, trustOpts lite.TrustOptions) (*lite.Client, error) {
-	httpProvider, err := litehttp.New(c.ChainID, c.RPCAddr)
+func (c *Chain) LiteClient(store litestore.Store, trustOpts lite.TrustOptions) (*lite.Client, error) {
+	httpProvider, err := c.liteProvider()
 	if err != nil {
 		return nil, err
 	}
 
-	// NOTE: currently we are discarding the very noisy lite client logs
-	// it would be nice if we could add a setting the chain or otherwise
-	// that allowed users to enable lite client logging. (maybe as a hidden prop
-	// on the Chain struct that users could pass in the config??)
-	logger := log.NewTMLogger(log.NewSyncWriter(ioutil.Discard))
+	witnesses, err := c.liteWitnesses(httpProvider)
+	if err != nil {
+		return nil, err
+	}
 
-	// TODO: provide actual witnesses!
 	return lite.NewClient(c.ChainID, trustOpts, httpProvider,
-		[]litep.Provider{httpProvider}, dbs.New(db, ""),
-		lite.Logger(logger))
+		witnesses, store, c.liteClientOptions()...)
 }
 
 // InitLiteClient instantantiates the lite client object and calls update
-func (c *Chain) InitLiteClient(db *dbm.GoLevelDB, trustOpts lite.TrustOptions) (*lite.Client, error) {
-	lc, err := c.LiteClient(db, trustOpts)
-	if err != nil {
-		return nil, err
-	}
-	_, err = lc.Update(time.Now())
-	if err != nil {
-		return nil, err
-	}
-	return lc, err
+func (c *Chain) InitLiteClient(trustOpts lite.TrustOptions) error {
+	return c.initLiteClient(func(store litestore.Store) (*lite.Client, error) {
+		lc, err := c.LiteClient(store, trustOpts)
+		if err != nil {
+			return nil, c.checkDivergence(err)
+		}
+		_, err = lc.Update(time.Now())
+		if err != nil {
+			return nil, c.checkDivergence(err)
+		}
+		return lc, nil
+	})
 }
 
 // TrustNodeInitClient trusts the configured node and initializes the lite client
-func (c *Chain) TrustNodeInitClient(db *dbm.GoLevelDB) (*lite.Client, error) {
+func (c *Chain) TrustNodeInitClient() error {
 	// fetch latest height from configured node
 	var (
 		height int64
@@ -185,54 +178,31 @@ func (c *Chain) TrustNodeInitClient(db *dbm.GoLevelDB) (*lite.Client, error) {
 		}
 		return nil
 	}); err != nil {
-		return nil, err
+		return err
 	}
 
 	// fetch header from configured node
 	header, err := c.QueryHeaderAtHeight(height)
 	if err != nil {
-		return nil, err
-	}
-
-	lc, err := c.LiteClient(db, c.TrustOptions(height, header.Hash().Bytes()))
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = lc.Update(time.Now())
-	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return lc, nil
+	return c.InitLiteClient(c.TrustOptions(height, header.Hash().Bytes()))
 }
 
-// NewLiteDB returns a new instance of the liteclient database connection
-// CONTRACT: must close the database connection when done with it (defer df())
-func (c *Chain) NewLiteDB() (db *dbm.GoLevelDB, df func(), err error) {
-	if err := retry.Do(func() error {
-		db, err = dbm.NewGoLevelDB(c.ChainID, liteDir(c.HomePath))
-		if err != nil {
-			return fmt.Errorf("can't open lite client database: %w", err)
-		}
-		return nil
-	}); err != nil {
-		return nil, nil, err
-	}
-
-	df = func() {
-		err := db.Close()
-		if err != nil {
-			panic(err)
-		}
+// DeleteLiteDB closes the lite client and removes its database on disk, forcing re-initialization
+func (c *Chain) DeleteLiteDB() error {
+	if err := c.CloseLite(); err != nil {
+		return err
 	}
-
-	return
+	return os.RemoveAll(liteDBDir(c.HomePath, c.ChainID))
 }
 
-// DeleteLiteDB removes the lite client database on disk, forcing re-initialization
-func (c *Chain) DeleteLiteDB() error {
-	return os.RemoveAll(filepath.Join(liteDir(c.HomePath), fmt.Sprintf("%s.db", c.ChainID)))
+// SetLiteCacheSize sets the number of trusted headers kept in the chain's lite
+// client database, older ones are pruned as new headers are verified. Zero keeps
+// every header.
+func (c *Chain) SetLiteCacheSize(size uint16) {
+	c.liteCacheSize = size
 }
 
 // TrustOptions returns lite.TrustOptions given a height and hash
@@ -249,65 +219,174 @@ func (c *Chain) GetLatestLiteHeader() (*tmclient.Header, error) {
 	return c.GetLiteSignedHeaderAtHeight(0)
 }
 
-// VerifyProof performs response proof verification.
+// VerifyProof verifies the merkle proof of a store query response against the app
+// hash of the lite client's trusted header at the following height. Proofs of
+// absence are verified for responses without a value.
 func (c *Chain) VerifyProof(queryPath string, resp abci.ResponseQuery) error {
-	// TODO: write this verify function
+	if c.SkipProofVerification {
+		return nil
+	}
+	if err := c.verifyProof(queryPath, resp); err != nil {
+		return fmt.Errorf("failed to verify proof of %s query at height %d on chain %s: %w",
+			queryPath, resp.Height, c.ChainID, err)
+	}
 	return nil
 }
 
-// ValidateTxResult takes a transaction and validates the proof against a stored root of trust
-func (c *Chain) ValidateTxResult(resTx *ctypes.ResultTx) (err error) {
-	// fetch the header at the height from the ResultTx from the lite database
-	check, err := c.GetLiteSignedHeaderAtHeight(resTx.Height - 1)
+func (c *Chain) verifyProof(queryPath string, resp abci.ResponseQuery) error {
+	storeName, err := parseQueryStorePath(queryPath)
 	if err != nil {
-		return
+		return err
+	}
+	if resp.Proof == nil {
+		return errors.New("response has no proof")
 	}
 
-	// validate the proof against that header
-	return resTx.Proof.Validate(check.Header.DataHash)
+	// the app hash for height H is in header H+1, which may not be committed yet
+	header, err := c.trustedHeaderAtHeight(resp.Height + 1)
+	if err != nil {
+		return err
+	}
+
+	kp := merkle.KeyPath{}
+	kp = kp.AppendKey([]byte(storeName), merkle.KeyEncodingURL)
+	kp = kp.AppendKey(resp.Key, merkle.KeyEncodingURL)
+
+	prt := rootmulti.DefaultProofRuntime()
+	if resp.Value == nil {
+		return prt.VerifyAbsence(resp.Proof, header.Header.AppHash, kp.String())
+	}
+	return prt.VerifyValue(resp.Proof, header.Header.AppHash, kp.String(), resp.Value)
 }
 
-// GetLatestLiteHeight uses the CLI utilities to pull the latest height from a given chain
-func (c *Chain) GetLatestLiteHeight() (int64, error) {
-	db, df, err := c.NewLiteDB()
+// trustedHeaderAtHeight returns the signed header at height, read from the lite
+// client's store if it is already trusted. Otherwise the header is waited for and
+// verified, which holds the lite client for the duration.
+func (c *Chain) trustedHeaderAtHeight(height int64) (*tmtypes.SignedHeader, error) {
+	if err := c.liteDiverged(); err != nil {
+		return nil, liteError(err)
+	}
+	if sh := c.lite.trustedHeader(height); sh != nil {
+		return sh, nil
+	}
+	if err := c.waitForHeight(height); err != nil {
+		return nil, err
+	}
+	header, err := c.UpdateLiteWithHeaderHeight(height)
 	if err != nil {
-		return -1, err
+		return nil, liteError(err)
 	}
-	defer df()
+	return &header.SignedHeader, nil
+}
 
-	client, err := c.LiteClientWithoutTrust(db)
-	if err != nil {
-		return -1, err
+// blockPollInterval is the time between queries for the latest height of a chain
+// while waiting for a block
+const blockPollInterval = 500 * time.Millisecond
+
+// blockHeights tracks the latest height known to be committed on a chain, shared
+// by copies of the chain, so that the next block is waited for once per height
+// rather than once per query
+type blockHeights struct {
+	sync.Mutex // held while polling for a block
+	latest     int64
+}
+
+// observe records that the chain has committed a block at height
+func (bh *blockHeights) observe(height int64) {
+	for {
+		latest := atomic.LoadInt64(&bh.latest)
+		if height <= latest || atomic.CompareAndSwapInt64(&bh.latest, latest, height) {
+			return
+		}
 	}
+}
 
-	return client.LastTrustedHeight()
+// waitForHeight blocks until the chain has committed a block at height, giving
+// up after the chain's timeout. Only one caller polls the chain at a time, the
+// others use the height it finds.
+func (c *Chain) waitForHeight(height int64) error {
+	bh := c.heights
+	if atomic.LoadInt64(&bh.latest) >= height {
+		return nil
+	}
+
+	bh.Lock()
+	defer bh.Unlock()
+	deadline := time.Now().Add(c.timeout)
+	for {
+		if latest := atomic.LoadInt64(&bh.latest); latest >= height {
+			return nil
+		}
+		latest, err := c.QueryLatestHeight()
+		switch {
+		case err != nil:
+			return err
+		case latest >= height:
+			bh.observe(latest)
+			return nil
+		case time.Now().After(deadline):
+			return fmt.Errorf("timed out waiting for block %d, latest is %d", height, latest)
+		}
+		time.Sleep(blockPollInterval)
+	}
 }
 
-// GetLiteSignedHeaderAtHeight returns a signed header at a particular height.
-func (c *Chain) GetLiteSignedHeaderAtHeight(height int64) (*tmclient.Header, error) {
-	// create database connection
-	db, df, err := c.NewLiteDB()
-	if err != nil {
-		return nil, err
+// ValidateTxResult takes a transaction and validates the proof against a stored root of trust
+func (c *Chain) ValidateTxResult(resTx *ctypes.ResultTx) (err error) {
+	if c.SkipProofVerification {
+		return nil
+	}
+	if err = c.validateTxResult(resTx); err != nil {
+		return fmt.Errorf("failed to verify tx %s at height %d on chain %s: %w", resTx.Hash, resTx.Height, c.ChainID, err)
 	}
-	defer df()
+	return nil
+}
 
-	client, err := c.LiteClientWithoutTrust(db)
-	if err != nil {
-		return nil, err
+func (c *Chain) validateTxResult(resTx *ctypes.ResultTx) error {
+	if !bytes.Equal(resTx.Proof.Data, resTx.Tx) {
+		return errors.New("proof is not of the returned tx")
 	}
 
-	sh, err := client.TrustedHeader(height)
+	// the txs of a block are committed to by the data hash of its own header
+	check, err := c.trustedHeaderAtHeight(resTx.Height)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	vs, _, err := client.TrustedValidatorSet(sh.Height)
+	// validate the proof against that header
+	return resTx.Proof.Validate(check.Header.DataHash)
+}
+
+// GetLatestLiteHeight uses the CLI utilities to pull the latest height from a given chain
+func (c *Chain) GetLatestLiteHeight() (height int64, err error) {
+	err = c.withLiteClient(func(client *lite.Client) (err error) {
+		height, err = client.LastTrustedHeight()
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return -1, err
 	}
+	return height, nil
+}
+
+// GetLiteSignedHeaderAtHeight returns a signed header at a particular height.
+func (c *Chain) GetLiteSignedHeaderAtHeight(height int64) (*tmclient.Header, error) {
+	var out *tmclient.Header
+	err := c.withLiteClient(func(client *lite.Client) error {
+		sh, err := client.TrustedHeader(height)
+		if err != nil {
+			return err
+		}
 
-	return &tmclient.Header{SignedHeader: *sh, ValidatorSet: vs}, nil
+		vs, _, err := client.TrustedValidatorSet(sh.Height)
+		if err != nil {
+			return err
+		}
+
+		out = &tmclient.Header{SignedHeader: *sh, ValidatorSet: vs}
+		return nil
+	})
+	return out, err
 }
 
 // ErrLiteNotInitialized returns the cannonical error for a an uninitialized lite client
@@ -315,14 +394,5 @@ var ErrLiteNotInitialized = errors.New("lite client is not initialized")
 
 // ForceInitLite forces initialization of the lite client from the configured node
 func (c *Chain) ForceInitLite() error {
-	db, df, err := c.NewLiteDB()
-	if err != nil {
-		return err
-	}
-	_, err = c.TrustNodeInitClient(db)
-	if err != nil {
-		return err
-	}
-	df()
-	return nil
+	return c.TrustNodeInitClient()
 }
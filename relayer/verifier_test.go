@@ -0,0 +1,146 @@
+package relayer
+
+import (
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/store/rootmulti"
+	storetypes "github.com/cosmos/cosmos-sdk/store/types"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	dbs "github.com/tendermint/tendermint/lite2/store/db"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// proofTestChain returns a chain whose lite client trusts a header committing to
+// the app hash of a multistore holding key in its ibc store, along with proven
+// query responses for key and for a key that isn't set
+func proofTestChain(t *testing.T, key, value []byte) (c *Chain, present, absent abci.ResponseQuery) {
+	store := rootmulti.NewStore(dbm.NewMemDB())
+	ibcKey, bankKey := storetypes.NewKVStoreKey("ibc"), storetypes.NewKVStoreKey("bank")
+	store.MountStoreWithDB(ibcKey, storetypes.StoreTypeIAVL, nil)
+	store.MountStoreWithDB(bankKey, storetypes.StoreTypeIAVL, nil)
+	require.NoError(t, store.LoadLatestVersion())
+
+	store.GetKVStore(ibcKey).Set(key, value)
+	store.GetKVStore(bankKey).Set(key, []byte("bank value"))
+	commit := store.Commit()
+
+	present = store.Query(abci.RequestQuery{Path: "/ibc/key", Data: key, Prove: true})
+	require.True(t, present.IsOK(), present.Log)
+	absent = store.Query(abci.RequestQuery{Path: "/ibc/key", Data: []byte("missing"), Prove: true})
+	require.True(t, absent.IsOK(), absent.Log)
+	require.Nil(t, absent.Value)
+
+	// the app hash of a height is committed to by the header of the next height
+	c = &Chain{ChainID: "ibc0", diverged: &divergence{}, lite: &liteHandle{store: dbs.New(dbm.NewMemDB(), "")}}
+	sh := &tmtypes.SignedHeader{Header: &tmtypes.Header{ChainID: "ibc0", Height: commit.Version + 1, AppHash: commit.Hash}}
+	require.NoError(t, c.lite.store.SaveSignedHeaderAndValidatorSet(sh, tmtypes.NewValidatorSet(nil)))
+	return c, present, absent
+}
+
+func TestVerifyProof(t *testing.T) {
+	c, present, absent := proofTestChain(t, []byte("commitments/ports/transfer"), []byte("commitment"))
+
+	tampered := present
+	tampered.Value = []byte("other commitment")
+	forged := absent
+	forged.Value = []byte("commitment")
+	unproven := present
+	unproven.Proof = nil
+	wrongKey := present
+	wrongKey.Key = []byte("commitments/ports/other")
+	wrongHeight := present
+	wrongHeight.Height--
+
+	tests := []struct {
+		name  string
+		path  string
+		resp  abci.ResponseQuery
+		valid bool
+	}{
+		{"value", "/store/ibc/key", present, true},
+		{"value without leading slash", "store/ibc/key", present, true},
+		{"absence", "/store/ibc/key", absent, true},
+		{"tampered value", "/store/ibc/key", tampered, false},
+		{"value of absent key", "/store/ibc/key", forged, false},
+		{"wrong store", "/store/bank/key", present, false},
+		{"wrong key", "/store/ibc/key", wrongKey, false},
+		{"no proof", "/store/ibc/key", unproven, false},
+		{"not a key query", "/store/ibc/subspace", present, false},
+		{"not a store query", "/custom/ibc/key", present, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := c.VerifyProof(tc.path, tc.resp)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+
+	// the header at the queried height holds the app hash of the previous height,
+	// so a response claiming that height doesn't verify against it
+	prev := &tmtypes.SignedHeader{Header: &tmtypes.Header{ChainID: "ibc0", Height: present.Height, AppHash: make([]byte, 32)}}
+	require.NoError(t, c.lite.store.SaveSignedHeaderAndValidatorSet(prev, tmtypes.NewValidatorSet(nil)))
+	require.Error(t, c.VerifyProof("/store/ibc/key", wrongHeight))
+
+	c.SkipProofVerification = true
+	require.NoError(t, c.VerifyProof("/store/ibc/key", tampered))
+}
+
+func TestIsQueryStoreWithProof(t *testing.T) {
+	tests := []struct {
+		path  string
+		proof bool
+	}{
+		{"/store/ibc/key", true},
+		{"store/ibc/key", true},
+		{"/store/ibc/subspace", false},
+		{"store/ibc/subspace", false},
+		{"/custom/ibc/key", false},
+		{"/store/ibc", false},
+		{"", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			require.Equal(t, tc.proof, isQueryStoreWithProof(tc.path))
+		})
+	}
+}
+
+func TestValidateTxResult(t *testing.T) {
+	txs := tmtypes.Txs{tmtypes.Tx("send packet"), tmtypes.Tx("other tx")}
+	c := &Chain{ChainID: "ibc0", diverged: &divergence{}, lite: &liteHandle{store: dbs.New(dbm.NewMemDB(), "")}}
+	sh := &tmtypes.SignedHeader{Header: &tmtypes.Header{ChainID: "ibc0", Height: 10, DataHash: txs.Hash()}}
+	require.NoError(t, c.lite.store.SaveSignedHeaderAndValidatorSet(sh, tmtypes.NewValidatorSet(nil)))
+
+	tests := []struct {
+		name  string
+		tx    tmtypes.Tx
+		proof tmtypes.TxProof
+		valid bool
+	}{
+		{"proven tx", txs[0], txs.Proof(0), true},
+		{"other proven tx", txs[1], txs.Proof(1), true},
+		{"tx differs from proof data", txs[1], txs.Proof(0), false},
+		{"proof data of another tx", txs[1], tmtypes.TxProof{RootHash: txs.Hash(), Data: txs[1], Proof: txs.Proof(0).Proof}, false},
+		{"tx not in block", tmtypes.Tx("forged"), tmtypes.TxProof{RootHash: txs.Hash(), Data: tmtypes.Tx("forged"), Proof: txs.Proof(0).Proof}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := c.validateTxResult(&ctypes.ResultTx{Height: 10, Tx: tc.tx, Proof: tc.proof})
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package relayer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	litep "github.com/tendermint/tendermint/lite2/provider"
+	litehttp "github.com/tendermint/tendermint/lite2/provider/http"
+)
+
+// ErrLiteDivergence is returned once a witness of a chain's lite client has
+// reported a validly signed header that differs from the primary's at the same
+// height. No more headers are taken from the primary until the relayer is restarted.
+var ErrLiteDivergence = errors.New("lite client witness diverged from the primary")
+
+// divergence records the first divergence seen between a chain's primary and
+// its witnesses, shared by copies of the chain
+type divergence struct {
+	sync.Mutex
+	err error
+}
+
+// liteWitnesses returns the providers the lite client cross-checks the headers
+// of the primary against. The primary is its own witness if the chain has no
+// witness-addrs, which leaves divergence undetected.
+func (c *Chain) liteWitnesses(primary litep.Provider) ([]litep.Provider, error) {
+	if len(c.WitnessAddrs) == 0 {
+		return []litep.Provider{primary}, nil
+	}
+
+	witnesses := make([]litep.Provider, 0, len(c.WitnessAddrs))
+	for _, addr := range c.WitnessAddrs {
+		w, err := litehttp.New(c.ChainID, addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid witness-addr (%s) for chain %s: %w", addr, c.ChainID, err)
+		}
+		witnesses = append(witnesses, w)
+	}
+	return witnesses, nil
+}
+
+// liteDiverged returns the divergence seen on the chain, if any
+func (c *Chain) liteDiverged() error {
+	if c.diverged == nil {
+		return nil
+	}
+	c.diverged.Lock()
+	defer c.diverged.Unlock()
+	return c.diverged.err
+}
+
+// checkDivergence records err if it reports a divergence between the primary and
+// a witness, returning it wrapped in ErrLiteDivergence. Other errors are returned as is.
+func (c *Chain) checkDivergence(err error) error {
+	if !isDivergence(err) || c.diverged == nil {
+		return err
+	}
+
+	c.diverged.Lock()
+	defer c.diverged.Unlock()
+	if c.diverged.err == nil {
+		c.diverged.err = fmt.Errorf("%w on chain %s, refusing headers from %s: %s",
+			ErrLiteDivergence, c.ChainID, c.ActiveRPCAddr(), err)
+		c.Error(c.diverged.err)
+	}
+	return c.diverged.err
+}
+
+// isDivergence returns true if the lite client failed because a witness has
+// a different header than the primary. The lite client doesn't export an error
+// type for this, so the message is matched.
+func isDivergence(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "from the witness")
+}